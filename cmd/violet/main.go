@@ -1,9 +1,42 @@
 package main
 
 import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
 	"github.com/braheezy/violet/internal/app"
+	"github.com/braheezy/violet/internal/cli"
+	"github.com/braheezy/violet/internal/config"
 )
 
 func main() {
-	app.Run()
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "violet: bad config:", err)
+		os.Exit(1)
+	}
+
+	fs := flag.NewFlagSet("violet", flag.ContinueOnError)
+	fs.StringVar(&cfg.Theme, "theme", cfg.Theme, "")
+	// The CLI's usage covers the flags too
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(os.Args[1:]); errors.Is(err, flag.ErrHelp) {
+		os.Exit(cli.Run(cfg, []string{"help"}))
+	} else if err != nil {
+		fmt.Fprintln(os.Stderr, "violet:", err)
+		os.Exit(2)
+	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, "violet:", err)
+		os.Exit(2)
+	}
+
+	// Subcommands are for scripts, otherwise it's the TUI
+	if fs.NArg() > 0 {
+		os.Exit(cli.Run(cfg, fs.Args()))
+	}
+	app.Run(cfg)
 }
@@ -9,15 +9,20 @@ import (
 	"io"
 	"log"
 	"os"
+	"strconv"
+	"time"
 
+	"github.com/braheezy/violet/internal/config"
 	"github.com/braheezy/violet/pkg/vagrant"
 	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	zone "github.com/lrstanley/bubblezone"
 )
 
-func Run() {
+// Run the TUI, set up the way cfg says.
+func Run(cfg config.Config) {
 	if os.Getenv("VIOLET_DEBUG") != "" {
 		if f, err := tea.LogToFile("violet-debug.log", "debug"); err != nil {
 			fmt.Println("Couldn't open a file for logging:", err)
@@ -29,21 +34,35 @@ func Run() {
 		// Set up a dummy logger that discards log output
 		log.SetOutput(io.Discard)
 	}
+	applySettings(cfg)
 	// Set the color palette for the application.
-	if lipgloss.HasDarkBackground() {
-		theme = defaultDarkTheme
+	if t, ok := themeByID(cfg.Theme); ok {
+		setTheme(t)
+	} else if lipgloss.HasDarkBackground() {
+		setTheme(defaultDarkTheme)
 	} else {
-		theme = defaultLightTheme
+		setTheme(defaultLightTheme)
+	}
+
+	client, err := cfg.NewVagrantClient()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
 	}
 
 	// Setup mouse tracking
 	zone.NewGlobal()
 
-	p := tea.NewProgram(newViolet(), tea.WithAltScreen(), tea.WithMouseAllMotion())
+	p := tea.NewProgram(newViolet(client), tea.WithAltScreen(), tea.WithMouseAllMotion())
 	p.SetWindowTitle("♡♡ violet ♡♡")
-	if _, err := p.Run(); err != nil {
+	model, err := p.Run()
+	if err != nil {
 		log.Fatalf("Could not start program :(\n%v\n", err)
 	}
+	// Don't leave Vagrant running behind our back
+	if v, ok := model.(Violet); ok {
+		v.jobs.stopAll(3 * client.GracePeriod)
+	}
 }
 
 // Complete app state (i.e. the BubbleTea model)
@@ -54,53 +73,118 @@ type Violet struct {
 	help help.Model
 	// To support help
 	keys helpKeyMap
-	// Spinner to show while commands are running
-	spinner currentSpinner
+	// Commands running in the background
+	jobs jobManager
+	// Live output of commands
+	logPane logPane
 	// Current terminal size
 	terminalWidth  int
 	terminalHeight int
 	errorMessage   string
+	// Something the user should know that isn't an error e.g. where an export went
+	notice string
+	// Extra detail about whatever the mouse is over
+	tooltip string
+	// Modal asking the user to confirm an action, if one is open
+	confirm *confirmDialog
+	// Snapshots of the selected machine, if the panel is open
+	snapshots *snapshotPanel
+	// Narrows the environments and machines shown, see filterEnvironments
+	filterInput textinput.Model
+	// Whether the filter is being typed
+	filtering bool
+	// Every machine at once, shown instead of the environment tabs when open
+	table *machineTable
+	// Commands running on marked machines, to sum up once they're done
+	bulkRuns []*bulkRun
+	// Details of the selected machine, if the pane is open
+	details *detailsPane
+	// Details of machines, by log target, kept until a job changes them
+	machineDetails map[string]*machineDetails
+	// Every theme, if the picker is open
+	themes *themePicker
+	// Installed boxes, shown instead of the environments when open
+	boxes *boxScreen
+	// How often machine states are refreshed, zero to never
+	refreshInterval time.Duration
+	refreshing      bool
+	lastRefreshed   time.Time
+	// Signals changes to Vagrant's machine index
+	indexChanges <-chan struct{}
 }
 
 func (v *Violet) setErrorMessage(message string) {
 	v.errorMessage = message
 }
 
-// Return the default Violet model
-func newViolet() Violet {
-	client, err := vagrant.NewVagrantClient()
-	if err != nil {
-		log.Fatal(err)
-	}
-
+// Return the default Violet model, running Vagrant with runner
+func newViolet(runner vagrant.Runner) Violet {
 	help := help.New()
 	help.ShowAll = true
+	filterInput := textinput.New()
+	filterInput.Prompt = "/ "
+	filterInput.Placeholder = "name, path or state:running provider:libvirt"
 
 	return Violet{
 		ecosystem: Ecosystem{
 			environments: nil,
-			client:       client,
+			client:       runner,
 		},
-		keys:    keys,
-		help:    help,
-		spinner: newSpinner(),
+		keys:            keys,
+		help:            help,
+		filterInput:     filterInput,
+		machineDetails:  make(map[string]*machineDetails),
+		jobs:            newJobManager(parallelism()),
+		logPane:         newLogPane(),
+		refreshInterval: refreshInterval(),
+		indexChanges:    watchMachineIndex(runner),
 	}
 }
 
-func (v Violet) Init() tea.Cmd {
-	return getInitialGlobalStatus
+// How violet is set up, from the config file.
+var settings = config.Default()
 
+// Use cfg from now on.
+func applySettings(cfg config.Config) {
+	settings = cfg
+	supportedMachineCommands = cfg.MachineCommands
+	supportedEnvCommands = cfg.EnvCommands
+	keys = newHelpKeyMap(cfg.Keys)
+	boxKeys = newBoxKeyMap(cfg.Keys)
+	snapshotKeys = newSnapshotKeyMap(cfg.Keys)
+	detailsKeys = newDetailsKeyMap(cfg.Keys)
+	themeKeys = newThemeKeyMap(cfg.Keys)
+	filterKeys = newFilterKeyMap(cfg.Keys)
 }
 
-// Runs on boot to get current Vagrant status on host.
-func getInitialGlobalStatus() tea.Msg {
-	client, err := vagrant.NewVagrantClient()
-	if err != nil {
-		log.Fatal(err)
+// How many commands may run at once, from VIOLET_PARALLELISM if it's set
+func parallelism() int {
+	if n, err := strconv.Atoi(os.Getenv("VIOLET_PARALLELISM")); err == nil {
+		return n
 	}
-	ecosystem, err := createEcosystem(client)
-	if err != nil {
-		return ecosystemErrMsg{err}
+	return settings.Parallelism
+}
+
+// How often to refresh, from VIOLET_REFRESH_INTERVAL if it's set e.g. 1m or 0 to never refresh
+func refreshInterval() time.Duration {
+	if interval, err := time.ParseDuration(os.Getenv("VIOLET_REFRESH_INTERVAL")); err == nil {
+		return interval
+	}
+	return settings.RefreshInterval
+}
+
+func (v Violet) Init() tea.Cmd {
+	return tea.Batch(v.getInitialGlobalStatus(), v.scheduleRefresh(), waitForMachineIndex(v.indexChanges))
+}
+
+// Create the tea.Cmd that runs on boot to get current Vagrant status on host.
+func (v Violet) getInitialGlobalStatus() tea.Cmd {
+	client := v.ecosystem.client
+	return func() tea.Msg {
+		ecosystem, err := createEcosystem(client)
+		if err != nil {
+			return ecosystemErrMsg{err}
+		}
+		return ecosystemMsg(ecosystem)
 	}
-	return ecosystemMsg(ecosystem)
 }
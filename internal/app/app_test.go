@@ -0,0 +1,36 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/braheezy/violet/pkg/vagrant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVioletWithSimulator(t *testing.T) {
+	sim := vagrant.NewSimulator(
+		vagrant.SimulatedMachine{ID: "1a2b3c4", Name: "web", Provider: "virtualbox", State: "running", Home: "/envs/site"},
+		vagrant.SimulatedMachine{ID: "5d6e7f8", Name: "db", Provider: "virtualbox", State: "not_created", Home: "/envs/site"},
+	)
+	v := newViolet(sim)
+	assert.Nil(t, v.indexChanges, "Verify the simulator isn't watched")
+
+	model, _ := v.Update(v.getInitialGlobalStatus()())
+	v = model.(Violet)
+	require.Len(t, v.ecosystem.environments, 1)
+	env := v.ecosystem.environments[0]
+	assert.Equal(t, "site", env.name)
+	require.Len(t, env.machines, 2)
+	assert.Equal(t, "not created", env.machines[1].state)
+
+	// Something else changed the machine
+	_, err := sim.Run(t.Context(), "", "up", "5d6e7f8", "--machine-readable")
+	require.NoError(t, err)
+	model, _ = v.Update(v.createMachineStatusCmd("5d6e7f8")())
+	v = model.(Violet)
+	assert.Equal(t, "running", v.ecosystem.environments[0].machines[1].state)
+
+	msg := v.createEnvStatusCmd("/envs/missing")()
+	assert.IsType(t, statusErrMsg{}, msg, "Verify Vagrant errors are reported")
+}
@@ -0,0 +1,269 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/braheezy/violet/internal/config"
+	"github.com/braheezy/violet/pkg/vagrant"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Box jobs all share one target: Vagrant doesn't like boxes being changed
+// concurrently, and they have nothing to do with any environment.
+var boxesTarget = jobTarget{label: "boxes", key: "boxes"}
+
+// Keys used on the box screen.
+type boxKeyMap struct {
+	Up      key.Binding
+	Down    key.Binding
+	Update  key.Binding
+	Remove  key.Binding
+	Prune   key.Binding
+	Refresh key.Binding
+	Close   key.Binding
+}
+
+var boxKeys = newBoxKeyMap(config.DefaultKeys())
+
+func newBoxKeyMap(k config.Keys) boxKeyMap {
+	closing := newBinding(k, "back", "box_close")
+	// The key that opened the screen closes it too
+	closing.SetKeys(append(closing.Keys(), k["boxes"]...)...)
+	return boxKeyMap{
+		Up:      newBinding(k, "", "up"),
+		Down:    newBinding(k, "", "down"),
+		Update:  newBinding(k, "update", "box_update"),
+		Remove:  newBinding(k, "remove", "box_remove"),
+		Prune:   newBinding(k, "prune", "box_prune"),
+		Refresh: newBinding(k, "refresh", "refresh"),
+		Close:   closing,
+	}
+}
+
+// boxScreen lists the boxes installed on the host and manages them.
+type boxScreen struct {
+	// Sorted by name, then provider, then version, as Vagrant lists them
+	boxes   []vagrant.Box
+	loading bool
+	err     string
+	// Newer versions of boxes, once the check is done
+	outdated []vagrant.OutdatedBox
+	checking bool
+	selected int
+	// Status of the last thing done that didn't need a job
+	hint string
+}
+
+func newBoxScreen() *boxScreen {
+	return &boxScreen{loading: true, checking: true}
+}
+
+// The newer version of box, if there is one.
+func (s *boxScreen) latestVersion(box vagrant.Box) string {
+	for _, outdated := range s.outdated {
+		if outdated.Name == box.Name && outdated.Provider == box.Provider && outdated.Current == box.Version {
+			return outdated.Latest
+		}
+	}
+	return ""
+}
+
+func (s *boxScreen) selectedBox() (vagrant.Box, bool) {
+	if s.selected >= len(s.boxes) {
+		return vagrant.Box{}, false
+	}
+	return s.boxes[s.selected], true
+}
+
+// boxListMsg is emitted when the installed boxes have been listed.
+type boxListMsg struct {
+	boxes []vagrant.Box
+	err   error
+}
+
+// boxOutdatedMsg is emitted when boxes have been checked for newer versions.
+type boxOutdatedMsg struct {
+	outdated []vagrant.OutdatedBox
+	err      error
+}
+
+// boxPrunePreviewMsg is emitted with the boxes a prune would remove.
+type boxPrunePreviewMsg struct {
+	boxes []vagrant.Box
+	err   error
+}
+
+// Create the tea.Cmd that lists the installed boxes.
+func (v *Violet) createBoxListCmd() tea.Cmd {
+	client := v.ecosystem.client
+	return func() tea.Msg {
+		log.Println("Listing boxes")
+		boxes, err := vagrant.ListBoxes(context.Background(), client)
+		return boxListMsg{boxes: boxes, err: err}
+	}
+}
+
+// Create the tea.Cmd that checks the installed boxes for newer versions.
+func (v *Violet) createBoxOutdatedCmd() tea.Cmd {
+	client := v.ecosystem.client
+	return func() tea.Msg {
+		log.Println("Checking for outdated boxes")
+		outdated, err := vagrant.OutdatedBoxes(context.Background(), client)
+		return boxOutdatedMsg{outdated: outdated, err: err}
+	}
+}
+
+// Create the tea.Cmd that finds out what a prune would remove, without removing anything.
+func (v *Violet) createBoxPrunePreviewCmd() tea.Cmd {
+	client := v.ecosystem.client
+	return func() tea.Msg {
+		log.Println("Previewing box prune")
+		boxes, err := vagrant.PruneBoxes(context.Background(), client, true)
+		return boxPrunePreviewMsg{boxes: boxes, err: err}
+	}
+}
+
+// Queue the job that runs `vagrant box <args>`.
+func (v *Violet) createBoxRunCmd(command string, label string, args []string) tea.Cmd {
+	client := v.ecosystem.client
+	target := boxesTarget
+	target.label = label
+	return v.jobs.submit(target, command, func(ctx context.Context) *vagrant.Stream {
+		log.Printf("Running %v", strings.Join(args, " "))
+		return client.Stream(ctx, "", args...)
+	})
+}
+
+// Switch to the box screen.
+func (v *Violet) openBoxScreen() tea.Cmd {
+	v.boxes = newBoxScreen()
+	return tea.Batch(v.createBoxListCmd(), v.createBoxOutdatedCmd())
+}
+
+// Handle a key press while the box screen is open.
+func (v *Violet) updateBoxScreen(msg tea.KeyMsg) tea.Cmd {
+	screen := v.boxes
+	switch {
+	case key.Matches(msg, boxKeys.Close):
+		v.boxes = nil
+	case key.Matches(msg, boxKeys.Up):
+		if screen.selected > 0 {
+			screen.selected--
+		}
+	case key.Matches(msg, boxKeys.Down):
+		if screen.selected < len(screen.boxes)-1 {
+			screen.selected++
+		}
+	case key.Matches(msg, boxKeys.Refresh):
+		screen.loading, screen.checking = true, true
+		return tea.Batch(v.createBoxListCmd(), v.createBoxOutdatedCmd())
+	case key.Matches(msg, boxKeys.Update):
+		box, ok := screen.selectedBox()
+		if !ok {
+			return nil
+		}
+		return v.createBoxRunCmd("box update", box.Name, vagrant.UpdateBoxArgs(box.Name, box.Provider))
+	case key.Matches(msg, boxKeys.Remove):
+		box, ok := screen.selectedBox()
+		if !ok {
+			return nil
+		}
+		v.confirm = newConfirmDialog(
+			fmt.Sprintf("Really remove %v %v for %v?", box.Name, box.Version, box.Provider),
+			"",
+			func(v *Violet) tea.Cmd {
+				return v.createBoxRunCmd("box remove", box.Name+" "+box.Version, vagrant.RemoveBoxArgs(box))
+			},
+		)
+	case key.Matches(msg, boxKeys.Prune):
+		screen.hint = "Looking for boxes to prune..."
+		return v.createBoxPrunePreviewCmd()
+	case key.Matches(msg, keys.Cancel):
+		v.jobs.cancelFor(boxesTarget.key)
+	case key.Matches(msg, keys.ScrollUp), key.Matches(msg, keys.ScrollDown):
+		return v.logPane.Update(msg)
+	}
+	return nil
+}
+
+// Ask to prune once it's known what would be removed.
+func (v *Violet) confirmBoxPrune(msg boxPrunePreviewMsg) {
+	if v.boxes == nil {
+		return
+	}
+	v.boxes.hint = ""
+	if msg.err != nil {
+		v.setErrorMessage(msg.err.Error())
+		return
+	}
+	if len(msg.boxes) == 0 {
+		v.boxes.hint = "No old versions of boxes to prune"
+		return
+	}
+	rows := []string{"Really prune these boxes?", ""}
+	for _, box := range msg.boxes {
+		rows = append(rows, fmt.Sprintf("  %v %v (%v)", box.Name, box.Version, box.Provider))
+	}
+	v.confirm = newConfirmDialog(strings.Join(rows, "\n"), "", func(v *Violet) tea.Cmd {
+		return v.createBoxRunCmd("box prune", "old boxes", vagrant.PruneBoxesArgs(false))
+	})
+}
+
+func (s *boxScreen) View() string {
+	title := panelTitleStyle.Render("Boxes")
+
+	var body string
+	switch {
+	case s.loading && s.boxes == nil:
+		body = panelHintStyle.Render("Looking for boxes...")
+	case s.err != "":
+		body = errorStyle.UnsetMargins().Render(s.err)
+	case len(s.boxes) == 0:
+		body = panelHintStyle.Render("No boxes installed")
+	default:
+		body = s.tableView()
+	}
+
+	status := s.hint
+	if status == "" && s.checking {
+		status = "Checking for newer versions..."
+	}
+	footer := panelHintStyle.Render(helpLine(boxKeys.Update, boxKeys.Remove, boxKeys.Prune, boxKeys.Refresh, keys.Cancel, boxKeys.Close))
+	if status != "" {
+		footer = lipgloss.JoinVertical(lipgloss.Left, panelHintStyle.Render(status), footer)
+	}
+
+	return panelStyle.Render(lipgloss.JoinVertical(lipgloss.Left, title, "", body, "", footer))
+}
+
+// Boxes grouped under their names, one row per provider, version and architecture.
+func (s *boxScreen) tableView() string {
+	var providerWidth, versionWidth, architectureWidth int
+	for _, box := range s.boxes {
+		providerWidth = max(providerWidth, len(box.Provider))
+		versionWidth = max(versionWidth, len(box.Version))
+		architectureWidth = max(architectureWidth, len(box.Architecture))
+	}
+
+	var rows []string
+	for i, box := range s.boxes {
+		if i == 0 || s.boxes[i-1].Name != box.Name {
+			rows = append(rows, boxNameStyle.Render(box.Name))
+		}
+		row := fmt.Sprintf("%-*v  %-*v  %-*v", providerWidth, box.Provider, versionWidth, box.Version, architectureWidth, box.Architecture)
+		if latest := s.latestVersion(box); latest != "" {
+			row += "  " + boxOutdatedStyle.Render("⬆ "+latest+" available")
+		}
+		if i == s.selected {
+			rows = append(rows, panelSelectedItemStyle.Render(row))
+		} else {
+			rows = append(rows, panelItemStyle.Render(row))
+		}
+	}
+	return strings.Join(rows, "\n")
+}
@@ -4,30 +4,6 @@ import (
 	"github.com/charmbracelet/lipgloss"
 )
 
-var (
-	defaultLargeButtonStyle = lipgloss.NewStyle().
-				Foreground(primaryColor).
-				Padding(1)
-	activeLargeButtonStyle = defaultLargeButtonStyle.
-				Foreground(secondaryColor).
-				Bold(true)
-	buttonLargeGroupStyle = lipgloss.NewStyle().
-				Border(lipgloss.RoundedBorder(), true).
-				BorderForeground(primaryColor).
-				Margin(0)
-
-	defaultSmallButtonStyle = lipgloss.NewStyle().
-				Foreground(primaryColor).
-				Margin(0, 1)
-	activeSmallButtonStyle = defaultSmallButtonStyle.
-				Foreground(secondaryColor).
-				Bold(true)
-	buttonSmallGroupStyle = lipgloss.NewStyle().
-				Margin(marginVertical, marginHorizontal, 0).
-				Border(lipgloss.RoundedBorder(), true).
-				BorderForeground(primaryColor)
-)
-
 type button struct {
 	content string
 	style   lipgloss.Style
@@ -0,0 +1,64 @@
+package app
+
+import (
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// confirmDialog is a modal that asks the user to confirm an action before it's taken.
+// Either y/N is enough, or the user has to type something, like the name of what's affected.
+type confirmDialog struct {
+	prompt string
+	// When set, this has to be typed to confirm instead of pressing y.
+	requiredInput string
+	input         textinput.Model
+	// What to do once confirmed
+	onConfirm func(v *Violet) tea.Cmd
+}
+
+func newConfirmDialog(prompt string, requiredInput string, onConfirm func(v *Violet) tea.Cmd) *confirmDialog {
+	input := textinput.New()
+	input.Placeholder = requiredInput
+	input.Focus()
+	return &confirmDialog{
+		prompt:        prompt,
+		requiredInput: requiredInput,
+		input:         input,
+		onConfirm:     onConfirm,
+	}
+}
+
+// Handle a key press. Returns whether the dialog is finished, and if so
+// whether the action was confirmed.
+func (d *confirmDialog) Update(msg tea.KeyMsg) (done bool, confirmed bool, cmd tea.Cmd) {
+	if msg.Type == tea.KeyEsc || msg.Type == tea.KeyCtrlC {
+		return true, false, nil
+	}
+	if d.requiredInput == "" {
+		// Anything but a yes is a no
+		return true, msg.String() == "y" || msg.String() == "Y", nil
+	}
+	if msg.Type == tea.KeyEnter {
+		if d.input.Value() == d.requiredInput {
+			return true, true, nil
+		}
+		return false, false, nil
+	}
+	d.input, cmd = d.input.Update(msg)
+	return false, false, cmd
+}
+
+func (d *confirmDialog) View() string {
+	var body string
+	if d.requiredInput == "" {
+		body = confirmHintStyle.Render("y to confirm, N to cancel")
+	} else {
+		body = lipgloss.JoinVertical(
+			lipgloss.Left,
+			confirmHintStyle.Render("Type "+d.requiredInput+" and press ⏎ to confirm, esc to cancel"),
+			d.input.View(),
+		)
+	}
+	return confirmDialogStyle.Render(lipgloss.JoinVertical(lipgloss.Left, confirmPromptStyle.Render(d.prompt), "", body))
+}
@@ -0,0 +1,47 @@
+package app
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+func typeKeys(d *confirmDialog, keys string) {
+	for _, r := range keys {
+		d.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+}
+
+func TestConfirmDialog(t *testing.T) {
+	t.Run("Verify y confirms", func(t *testing.T) {
+		d := newConfirmDialog("Really?", "", nil)
+		done, confirmed, _ := d.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+		assert.True(t, done)
+		assert.True(t, confirmed)
+	})
+	t.Run("Verify anything else cancels", func(t *testing.T) {
+		d := newConfirmDialog("Really?", "", nil)
+		done, confirmed, _ := d.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		assert.True(t, done)
+		assert.False(t, confirmed)
+	})
+	t.Run("Verify the required input must be typed", func(t *testing.T) {
+		d := newConfirmDialog("Really?", "env1", nil)
+		typeKeys(d, "env")
+		done, _, _ := d.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		assert.False(t, done, "Partial input is not enough")
+
+		typeKeys(d, "1")
+		done, confirmed, _ := d.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		assert.True(t, done)
+		assert.True(t, confirmed)
+	})
+	t.Run("Verify esc cancels typed input", func(t *testing.T) {
+		d := newConfirmDialog("Really?", "env1", nil)
+		typeKeys(d, "env1")
+		done, confirmed, _ := d.Update(tea.KeyMsg{Type: tea.KeyEsc})
+		assert.True(t, done)
+		assert.False(t, confirmed)
+	})
+}
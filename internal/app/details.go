@@ -0,0 +1,322 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/atotto/clipboard"
+	"github.com/braheezy/violet/internal/config"
+	"github.com/braheezy/violet/pkg/vagrant"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// How wide the explanation of a machine's state gets before it wraps
+const detailsTextWidth = 60
+
+// Keys used inside the details pane.
+type detailsKeyMap struct {
+	Up      key.Binding
+	Down    key.Binding
+	Pick    key.Binding
+	Refresh key.Binding
+	Open    key.Binding
+	Copy    key.Binding
+	Close   key.Binding
+}
+
+var detailsKeys = newDetailsKeyMap(config.DefaultKeys())
+
+func newDetailsKeyMap(k config.Keys) detailsKeyMap {
+	closing := newBinding(k, "close", "details_close")
+	// The key that opened the pane closes it too
+	closing.SetKeys(append(closing.Keys(), k["details"]...)...)
+	return detailsKeyMap{
+		Up:      newBinding(k, "", "up"),
+		Down:    newBinding(k, "", "down"),
+		Pick:    newBinding(k, "pick port", "up", "down"),
+		Refresh: newBinding(k, "ask again", "details_refresh"),
+		Open:    newBinding(k, "open", "details_open"),
+		Copy:    newBinding(k, "copy url", "details_copy"),
+		Close:   closing,
+	}
+}
+
+// What each kind of state means, for when Vagrant doesn't explain it, see stateKinds.
+var stateExplanations = map[string]string{
+	"running":     "The machine is up and running. Halt it to shut it down, or suspend it to save its state for later.",
+	"changing":    "The machine is on its way to another state. Vagrant will say which once it gets there.",
+	"suspended":   "The machine's state is saved and it isn't running. Resume it to carry on where it left off.",
+	"stopped":     "The machine is shut down. Bring it up to start it again.",
+	"broken":      "The provider can't manage the machine the way it is. Bring it up to try again, or destroy and recreate it.",
+	"not created": "The machine hasn't been created yet. Bring it up to create it.",
+}
+
+// Everything known about a machine, as of when it was asked for.
+type machineDetails struct {
+	// Home of the environment the machine is in
+	home    string
+	fetched time.Time
+	status  vagrant.MachineStatus
+	// Empty when Vagrant didn't record it
+	box vagrant.Box
+	// nil unless the machine is running
+	ssh *vagrant.SSHConfig
+	// Empty until the machine has been created
+	ports         []vagrant.ForwardedPort
+	syncedFolders []vagrant.SyncedFolder
+	vagrantfile   string
+	err           string
+}
+
+// The state of the machine in Vagrant's words, or ours if it had none.
+func (d *machineDetails) explanation() string {
+	if text := strings.Join(strings.Fields(d.status.StateHumanLong), " "); text != "" {
+		return text
+	}
+	return stateExplanations[stateKinds[strings.ReplaceAll(d.status.State, "_", " ")]]
+}
+
+// detailsPane shows everything Vagrant can say about a machine.
+type detailsPane struct {
+	machine Machine
+	target  jobTarget
+	// Index of the forwarded port to open or copy
+	selectedPort int
+}
+
+// machineDetailsMsg is emitted when the details of a machine have been gathered.
+type machineDetailsMsg struct {
+	// key identifies the machine
+	key     string
+	details *machineDetails
+}
+
+// Create the tea.Cmd that gathers the details of machine. Only the state needs Vagrant
+// to answer, the rest is left out when it isn't known.
+func (v *Violet) createMachineDetailsCmd(target jobTarget, machine Machine) tea.Cmd {
+	client := v.ecosystem.client
+	return func() tea.Msg {
+		ctx := context.Background()
+		details := &machineDetails{home: target.home, fetched: time.Now()}
+		log.Printf("Getting details of %v", target.label)
+
+		var err error
+		if target.machineID != "" {
+			details.status, err = vagrant.GetMachineStatus(ctx, client, "", target.machineID)
+		} else {
+			details.status, err = vagrant.GetMachineStatus(ctx, client, target.home, target.machineName)
+		}
+		if err != nil {
+			details.err = err.Error()
+			return machineDetailsMsg{key: target.key, details: details}
+		}
+		// Vagrant can only say how to connect once the machine is up
+		if details.status.State == "running" && target.machineID != "" {
+			if ssh, err := vagrant.GetSSHConfig(ctx, client, target.machineID); err == nil {
+				details.ssh = &ssh
+			}
+		}
+		if target.machineID != "" && details.status.State != "not_created" {
+			details.ports, _ = vagrant.GetPorts(ctx, client, target.machineID)
+		}
+		dataDir := vagrant.MachineDataDir(target.home, machine.name, machine.provider)
+		details.box, _ = vagrant.ReadBoxMeta(dataDir)
+		details.syncedFolders, _ = vagrant.ReadSyncedFolders(dataDir)
+		details.vagrantfile, _ = vagrant.FindVagrantfile(target.home)
+		return machineDetailsMsg{key: target.key, details: details}
+	}
+}
+
+// Open the details pane for the selected machine, asking Vagrant unless they're already known.
+func (v *Violet) openDetailsPane() tea.Cmd {
+	machine, err := v.ecosystem.currentMachine()
+	if err != nil {
+		v.setErrorMessage(err.Error())
+		return nil
+	}
+	v.details = &detailsPane{machine: *machine, target: machineTarget(v.ecosystem.currentEnv(), machine)}
+	if v.machineDetails[v.details.target.key] != nil {
+		return nil
+	}
+	return v.createMachineDetailsCmd(v.details.target, v.details.machine)
+}
+
+// Handle a key press while the details pane is open.
+func (v *Violet) updateDetailsPane(msg tea.KeyMsg) tea.Cmd {
+	pane := v.details
+	var ports []vagrant.ForwardedPort
+	if details := v.machineDetails[pane.target.key]; details != nil {
+		ports = details.ports
+	}
+	switch {
+	case key.Matches(msg, detailsKeys.Close):
+		v.details = nil
+	case key.Matches(msg, detailsKeys.Refresh):
+		delete(v.machineDetails, pane.target.key)
+		return v.createMachineDetailsCmd(pane.target, pane.machine)
+	case key.Matches(msg, detailsKeys.Up):
+		if pane.selectedPort > 0 {
+			pane.selectedPort--
+		}
+	case key.Matches(msg, detailsKeys.Down):
+		if pane.selectedPort < len(ports)-1 {
+			pane.selectedPort++
+		}
+	case key.Matches(msg, detailsKeys.Open), key.Matches(msg, detailsKeys.Copy):
+		if pane.selectedPort >= len(ports) {
+			return nil
+		}
+		url := portURL(ports[pane.selectedPort])
+		if key.Matches(msg, detailsKeys.Copy) {
+			return createCopyURLCmd(url)
+		}
+		return createOpenURLCmd(url)
+	}
+	return nil
+}
+
+// Where a web service behind port is on the host, assuming guests serve HTTPS on 443 and HTTP elsewhere.
+func portURL(port vagrant.ForwardedPort) string {
+	if port.Guest == 443 {
+		return fmt.Sprintf("https://localhost:%v", port.Host)
+	}
+	return fmt.Sprintf("http://localhost:%v", port.Host)
+}
+
+// Open url with whatever the desktop opens URLs with, usually a browser. Replaced in tests.
+var openURL = func(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	// xdg-open may only exit once the browser does
+	go cmd.Wait()
+	return nil
+}
+
+// Put text on the system clipboard. Replaced in tests.
+var copyToClipboard = clipboard.WriteAll
+
+// urlActionMsg is emitted once a URL has been opened or copied.
+type urlActionMsg struct {
+	notice string
+	err    error
+}
+
+func createOpenURLCmd(url string) tea.Cmd {
+	return func() tea.Msg {
+		if err := openURL(url); err != nil {
+			return urlActionMsg{err: fmt.Errorf("couldn't open %v: %w", url, err)}
+		}
+		return urlActionMsg{notice: "Opened " + url}
+	}
+}
+
+func createCopyURLCmd(url string) tea.Cmd {
+	return func() tea.Msg {
+		if err := copyToClipboard(url); err != nil {
+			return urlActionMsg{err: fmt.Errorf("couldn't copy %v: %w", url, err)}
+		}
+		return urlActionMsg{notice: "Copied " + url}
+	}
+}
+
+// Forget the details of every machine target covers, as a job has just changed them.
+// They're gathered again if they're being shown.
+func (v *Violet) forgetMachineDetails(target jobTarget) tea.Cmd {
+	for key, details := range v.machineDetails {
+		if key == target.key || target.isEnv() && details.home == target.home {
+			delete(v.machineDetails, key)
+		}
+	}
+	if v.details != nil && v.machineDetails[v.details.target.key] == nil {
+		return v.createMachineDetailsCmd(v.details.target, v.details.machine)
+	}
+	return nil
+}
+
+// Render a labelled section of the pane, with each value on its own line.
+func detailsRow(label string, values ...string) string {
+	return lipgloss.JoinHorizontal(lipgloss.Top, panelTitleStyle.Width(14).Render(label), strings.Join(values, "\n"))
+}
+
+func (p *detailsPane) View(details *machineDetails) string {
+	title := panelTitleStyle.Render("Details of " + p.target.label)
+	footer := panelHintStyle.Render(helpLine(detailsKeys.Refresh, detailsKeys.Close))
+	if details != nil && len(details.ports) > 0 {
+		footer = panelHintStyle.Render(helpLine(detailsKeys.Pick, detailsKeys.Open, detailsKeys.Copy, detailsKeys.Refresh, detailsKeys.Close))
+	}
+
+	var body string
+	switch {
+	case details == nil:
+		body = panelHintStyle.Render("Asking Vagrant...")
+	case details.err != "":
+		body = errorStyle.UnsetMargins().Render(details.err)
+	default:
+		state := details.status.StateHumanShort
+		if state == "" {
+			state = strings.ReplaceAll(details.status.State, "_", " ")
+		}
+		rows := []string{
+			detailsRow("State",
+				cardStatusStyle.Foreground(statusColor(details.status.State)).Render(state),
+				panelHintStyle.Width(detailsTextWidth).Render(details.explanation())),
+			detailsRow("Provider", details.status.Provider),
+		}
+		if p.machine.machineID != "" {
+			rows = append(rows, detailsRow("ID", p.machine.machineID))
+		}
+		if details.box.Name != "" {
+			rows = append(rows, detailsRow("Box", strings.TrimSpace(details.box.Name+" "+details.box.Version)))
+		}
+		if details.ssh != nil {
+			rows = append(rows, detailsRow("SSH",
+				fmt.Sprintf("%v@%v port %v", details.ssh.User, details.ssh.HostName, details.ssh.Port),
+				panelHintStyle.Render(details.ssh.IdentityFile)))
+		} else {
+			rows = append(rows, detailsRow("SSH", panelHintStyle.Render("Once it's running")))
+		}
+		var ports []string
+		for i, port := range details.ports {
+			line := fmt.Sprintf("localhost:%v → %v", port.Host, port.Guest)
+			if i == p.selectedPort {
+				ports = append(ports, panelSelectedItemStyle.UnsetPaddingLeft().Render("▸ "+line))
+			} else {
+				ports = append(ports, "  "+line)
+			}
+		}
+		if len(ports) > 0 {
+			rows = append(rows, detailsRow("Ports", ports...))
+		}
+		var folders []string
+		for _, folder := range details.syncedFolders {
+			folders = append(folders, fmt.Sprintf("%v → %v %v", folder.HostPath, folder.GuestPath, panelHintStyle.Render(folder.Type)))
+		}
+		if len(folders) > 0 {
+			rows = append(rows, detailsRow("Synced", folders...))
+		}
+		if details.vagrantfile != "" {
+			rows = append(rows, detailsRow("Vagrantfile", details.vagrantfile))
+		}
+		rows = append(rows, "", panelHintStyle.Render("As of "+details.fetched.Format(time.TimeOnly)))
+		body = strings.Join(rows, "\n")
+	}
+
+	return panelStyle.Render(lipgloss.JoinVertical(lipgloss.Left, title, "", body, "", footer))
+}
@@ -0,0 +1,118 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/braheezy/violet/pkg/vagrant"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	detailsKey = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("i")}
+	esc        = tea.KeyMsg{Type: tea.KeyEsc}
+)
+
+// Update v with msg, then with whatever its command returns.
+func pressAndWait(t *testing.T, v Violet, msg tea.Msg) Violet {
+	t.Helper()
+	model, cmd := v.Update(msg)
+	require.NotNil(t, cmd)
+	return press(model.(Violet), cmd())
+}
+
+func TestDetailsPane(t *testing.T) {
+	home := t.TempDir()
+	dataDir := vagrant.MachineDataDir(home, "web", "virtualbox")
+	require.NoError(t, os.MkdirAll(dataDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "box_meta"), []byte(`{"name":"bento/ubuntu-22.04","version":"202309.08.0","provider":"virtualbox"}`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "synced_folders"), []byte(`{"virtualbox":{"/vagrant":{"guestpath":"/vagrant","hostpath":"`+home+`"}}}`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(home, "Vagrantfile"), nil, 0o644))
+	sim := vagrant.NewSimulator(
+		vagrant.SimulatedMachine{ID: "a1b2c3d", Name: "web", Provider: "virtualbox", State: "running", Home: home, Ports: []vagrant.ForwardedPort{{Guest: 80, Host: 8080}}},
+		vagrant.SimulatedMachine{ID: "e4f5a6b", Name: "db", Provider: "virtualbox", State: "poweroff", Home: home},
+	)
+	eco, err := createEcosystem(sim)
+	require.NoError(t, err)
+	v := press(newViolet(sim), ecosystemMsg(eco))
+
+	v = press(v, detailsKey)
+	assert.Nil(t, v.details, "Verify environments don't have details")
+
+	model, cmd := press(v, space).Update(detailsKey)
+	v = model.(Violet)
+	require.NotNil(t, v.details)
+	assert.Contains(t, v.overlayView(), "Asking Vagrant...")
+	v = press(v, cmd())
+	details := v.machineDetails[v.details.target.key]
+	require.NotNil(t, details)
+	assert.Equal(t, "running", details.status.State)
+	assert.Equal(t, vagrant.Box{Name: "bento/ubuntu-22.04", Provider: "virtualbox", Version: "202309.08.0"}, details.box)
+	require.NotNil(t, details.ssh)
+	assert.Equal(t, "2222", details.ssh.Port)
+	assert.Equal(t, []vagrant.SyncedFolder{{HostPath: home, GuestPath: "/vagrant", Type: "virtualbox"}}, details.syncedFolders)
+	assert.Equal(t, filepath.Join(home, "Vagrantfile"), details.vagrantfile)
+	assert.Equal(t, []vagrant.ForwardedPort{{Guest: 22, Host: 2222}, {Guest: 80, Host: 8080}}, details.ports)
+	view := v.overlayView()
+	assert.Contains(t, view, "Details of "+eco.environments[0].name+"/web")
+	assert.Contains(t, view, "bento/ubuntu-22.04 202309.08.0")
+	assert.Contains(t, view, "vagrant@127.0.0.1 port 2222")
+	assert.Contains(t, view, "up and running", "Verify the state is explained when Vagrant doesn't")
+	assert.Contains(t, view, "localhost:8080 → 80")
+
+	// Closed and opened again, they're remembered
+	v = press(v, esc)
+	assert.Nil(t, v.details)
+	_, cmd = v.Update(detailsKey)
+	assert.Nil(t, cmd)
+
+	// Until a command changes the machine
+	v = press(v, esc, enter)
+	v = press(v, jobDoneMsg{id: 1})
+	assert.Empty(t, v.machineDetails)
+
+	v = pressAndWait(t, press(v, down), detailsKey)
+	details = v.machineDetails[v.details.target.key]
+	require.NotNil(t, details)
+	assert.Nil(t, details.ssh)
+	assert.Empty(t, details.box.Name, "Verify missing files are left out")
+	assert.Contains(t, v.overlayView(), "Once it's running")
+	assert.Contains(t, v.overlayView(), "shut down")
+}
+
+func TestDetailsPanePorts(t *testing.T) {
+	originalOpen, originalCopy := openURL, copyToClipboard
+	t.Cleanup(func() { openURL, copyToClipboard = originalOpen, originalCopy })
+	var opened, copied []string
+	openURL = func(url string) error {
+		opened = append(opened, url)
+		return nil
+	}
+	copyToClipboard = func(text string) error {
+		copied = append(copied, text)
+		return errors.New("no clipboard here")
+	}
+
+	sim := vagrant.NewSimulator(vagrant.SimulatedMachine{
+		ID: "a1b2c3d", Name: "web", Provider: "virtualbox", State: "running", Home: "/projects/site",
+		Ports: []vagrant.ForwardedPort{{Guest: 80, Host: 8080}, {Guest: 443, Host: 8443}},
+	})
+	eco, err := createEcosystem(sim)
+	require.NoError(t, err)
+	v := press(newViolet(sim), ecosystemMsg(eco), space)
+	v = pressAndWait(t, v, detailsKey)
+
+	v = pressAndWait(t, press(v, down), tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("o")})
+	assert.Equal(t, []string{"http://localhost:8080"}, opened)
+	assert.Equal(t, "Opened http://localhost:8080", v.notice)
+
+	v = press(v, down, down)
+	assert.Equal(t, 2, v.details.selectedPort, "Verify the selection stops at the last port")
+	v = pressAndWait(t, v, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	assert.Equal(t, []string{"https://localhost:8443"}, copied)
+	assert.Contains(t, v.errorMessage, "no clipboard here")
+}
@@ -1,10 +1,13 @@
 package app
 
 import (
+	"context"
 	"errors"
-	"path"
+	"os/exec"
+	"path/filepath"
 	"strings"
 
+	"github.com/braheezy/violet/internal/inventory"
 	"github.com/braheezy/violet/pkg/vagrant"
 	"github.com/charmbracelet/bubbles/paginator"
 	"github.com/charmbracelet/lipgloss"
@@ -16,7 +19,7 @@ type Ecosystem struct {
 	// Collection of all Vagrant environments
 	environments []Environment
 	// Reference to a Vagrant client to run commands with
-	client *vagrant.VagrantClient
+	client vagrant.Runner
 	// Buttons to allow the user to run commands
 	machineCommands machineCommandButtons
 	envCommands     envCommandButtons
@@ -25,6 +28,12 @@ type Ecosystem struct {
 	selectedMachine int
 	// Helper to paginate the list of environments
 	envPager environmentPager
+	// Only environments and machines that match this are shown, see filterEnvironments
+	filter string
+	// Every environment while the filter hides some, see everything
+	unfiltered []Environment
+	// Machines marked for bulk runs, by log target
+	marked map[string]bool
 }
 
 // Updates for the entire ecosystem. Usually with results from `global-status`
@@ -44,71 +53,41 @@ func (ep *environmentPager) hasMultiplePages() bool {
 	return ep.pg.TotalPages > 1
 }
 
-// Call `global-status` and translate result into a new Ecosystem
-func createEcosystem(client *vagrant.VagrantClient) (Ecosystem, error) {
-	// Fetch (not stream) the current global status
-	result, err := client.GetGlobalStatus()
+// Get every machine Vagrant knows about and translate the result into a new Ecosystem
+func createEcosystem(client vagrant.Runner) (Ecosystem, error) {
+	// Usually straight from the machine index, see GlobalStatus
+	found, err := inventory.Load(context.Background(), client)
 	var nilEcosystem Ecosystem
 
 	if err != nil {
 		return nilEcosystem, ecosystemErrMsg{err}
 	}
 
-	results := vagrant.ParseVagrantOutput(result)
-	if results == nil {
+	if len(found) == 0 {
 		return nilEcosystem, nil
 	}
 
-	var machines []Machine
-	for _, machineInfo := range results {
-		machine := Machine{
-			machineID: machineInfo.MachineID,
-			provider:  machineInfo.Fields["provider-name"],
-			state:     strings.Replace(machineInfo.Fields["state"], "_", " ", -1),
-			home:      machineInfo.Fields["machine-home"],
-		}
-		machines = append(machines, machine)
-	}
-	// Create different envs by grouping machines based on machine-home
-	type EnvironmentGroup struct {
-		Name     string
-		Machines []Machine
-	}
-	var envGroups []EnvironmentGroup
-	for _, machine := range machines {
-		found := false
-		for i, env := range envGroups {
-			// TODO: Bug if two different paths have the same folder name e.g. /foo/env1 and /bar/env1 will incorrectly be treated the same
-			if env.Name == path.Base(machine.home) {
-				envGroups[i].Machines = append(envGroups[i].Machines, machine)
-				found = true
-				break
-			}
-		}
-		if !found {
-			env := EnvironmentGroup{
-				Name:     path.Base(machine.home),
-				Machines: []Machine{machine},
-			}
-			envGroups = append(envGroups, env)
-		}
-	}
-
 	var environments []Environment
-	for _, envGroup := range envGroups {
-		if len(envGroup.Machines) > 0 {
-			env := Environment{
-				name:     envGroup.Name,
-				machines: envGroup.Machines,
-				home:     envGroup.Machines[0].home,
-				hasFocus: true,
-			}
-			environments = append(environments, env)
+	for _, env := range found {
+		environment := Environment{
+			name:     env.Name,
+			home:     env.Home,
+			hasFocus: settings.DefaultFocus == "environment",
+		}
+		for _, machine := range env.Machines {
+			environment.machines = append(environment.machines, Machine{
+				machineID: machine.ID,
+				name:      machine.Name,
+				provider:  machine.Provider,
+				state:     strings.Replace(machine.State, "_", " ", -1),
+				home:      machine.Home,
+			})
 		}
+		environments = append(environments, environment)
 	}
 
 	pager := paginator.New()
-	pager.PerPage = 5
+	pager.PerPage = settings.PageSize
 	pager.SetTotalPages(len(environments))
 
 	return Ecosystem{
@@ -120,9 +99,153 @@ func createEcosystem(client *vagrant.VagrantClient) (Ecosystem, error) {
 	}, nil
 }
 
+// Replace the environments with fresh ones, keeping what the user had selected
+// and machine names fresh doesn't know.
+func (e *Ecosystem) refresh(fresh Ecosystem) {
+	old := e.everything()
+	for i := range fresh.environments {
+		env := &fresh.environments[i]
+		for _, old := range old {
+			if old.home != env.home {
+				continue
+			}
+			env.selectedCommand = old.selectedCommand
+			env.hasFocus = old.hasFocus
+			for j := range env.machines {
+				machine := &env.machines[j]
+				for _, oldMachine := range old.machines {
+					if oldMachine.machineID == machine.machineID {
+						if machine.name == "" {
+							machine.name = oldMachine.name
+						}
+						machine.selectedCommand = oldMachine.selectedCommand
+					}
+				}
+			}
+		}
+	}
+
+	if old == nil {
+		e.envPager = fresh.envPager
+	}
+	e.show(fresh.environments)
+}
+
+// Show environments, or the ones that match the filter, keeping the selected environment
+// and machine selected if they're still shown.
+func (e *Ecosystem) show(environments []Environment) {
+	// Found again by what they are, their place may have changed
+	selectedHome, selectedMachine := "", ""
+	if e.hasSelectedEnv() {
+		selectedHome = e.currentEnv().home
+		if machine, err := e.currentMachine(); err == nil {
+			selectedMachine = machine.logTarget()
+		}
+	}
+
+	e.unfiltered = nil
+	if e.filter != "" {
+		e.unfiltered = environments
+		environments = filterEnvironments(environments, e.filter)
+	}
+
+	pager := e.envPager
+	e.environments = environments
+	if len(e.environments) > 0 {
+		pager.pg.SetTotalPages(len(e.environments))
+	} else {
+		// Nothing matches the filter, which is still a page
+		pager.pg.TotalPages = 1
+	}
+	if !pager.hasMultiplePages() {
+		pager.moreIsSelected = false
+		pager.backIsSelected = false
+	}
+	if pager.pg.Page >= pager.pg.TotalPages {
+		pager.pg.Page = max(pager.pg.TotalPages-1, 0)
+	}
+	e.envPager = pager
+
+	// Follow the selected environment to wherever it is now
+	if pager.moreIsSelected || pager.backIsSelected {
+		e.selectedEnv = -1
+	} else {
+		e.selectedEnv = min(max(e.selectedEnv, 0), max(len(e.environments)-1, 0))
+		for i, env := range e.environments {
+			if env.home == selectedHome {
+				e.selectedEnv = i
+			}
+		}
+		if len(e.environments) > 0 {
+			e.envPager.pg.Page = e.selectedEnv / e.envPager.pg.PerPage
+		}
+	}
+	if e.hasSelectedEnv() {
+		e.selectedMachine = min(e.selectedMachine, max(len(e.currentEnv().machines)-1, 0))
+		for i := range e.currentEnv().machines {
+			if e.currentEnv().machines[i].logTarget() == selectedMachine {
+				e.selectedMachine = i
+			}
+		}
+	}
+}
+
+// Show only the environments and machines that match query, jumping to the best match.
+func (e *Ecosystem) setFilter(query string) {
+	query = strings.TrimSpace(query)
+	if query == e.filter {
+		return
+	}
+	environments := e.everything()
+	e.filter = query
+	e.envPager.moreIsSelected = false
+	e.envPager.backIsSelected = false
+	e.show(environments)
+	if query != "" && len(e.environments) > 0 {
+		e.selectedEnv = 0
+		e.selectedMachine = 0
+		e.envPager.pg.Page = 0
+	}
+}
+
+// Every environment, whether the filter shows it or not.
+func (e *Ecosystem) everything() []Environment {
+	if e.filter == "" {
+		return e.environments
+	}
+	// Keep what's been done to the shown ones e.g. the selected command
+	for _, shown := range e.environments {
+		for i := range e.unfiltered {
+			env := &e.unfiltered[i]
+			if env.home != shown.home {
+				continue
+			}
+			env.selectedCommand = shown.selectedCommand
+			env.hasFocus = shown.hasFocus
+			for _, machine := range shown.machines {
+				for j := range env.machines {
+					if env.machines[j].sameAs(machine) {
+						env.machines[j] = machine
+					}
+				}
+			}
+		}
+	}
+	return e.unfiltered
+}
+
+// Change every environment with f, whether the filter shows it or not.
+func (e *Ecosystem) change(f func(environments []Environment)) {
+	environments := e.everything()
+	f(environments)
+	if e.filter != "" {
+		e.show(environments)
+	}
+}
+
 // Simple helper to get the specific machine the user is interacting with
 func (e *Ecosystem) currentMachine() (*Machine, error) {
-	if e.selectedEnv >= len(e.environments) {
+	if !e.hasSelectedEnv() {
 		return nil, errors.New("tried to access environment outside of ecosystem")
 	} else if e.selectedMachine >= len(e.environments[e.selectedEnv].machines) {
 		return nil, errors.New("tried to access machine outside of ecosystem")
@@ -131,15 +254,53 @@ func (e *Ecosystem) currentMachine() (*Machine, error) {
 	}
 }
 
+// Runners that can hand the terminal over to Vagrant, like VagrantClient.
+type interactiveRunner interface {
+	Command(dir string, args ...string) *exec.Cmd
+}
+
+// The command that opens a shell on machine, with the Vagrant and environment the client is set up with.
+func (e *Ecosystem) sshCommand(machine *Machine) (*exec.Cmd, error) {
+	runner, ok := e.client.(interactiveRunner)
+	if !ok {
+		return nil, errors.New("opening a shell needs Vagrant to be installed")
+	}
+	if machine.provider == "docker" {
+		return runner.Command(machine.home, "docker-exec", machine.name, "-it", "--", "/bin/sh"), nil
+	}
+	return runner.Command("", "ssh", machine.machineID), nil
+}
+
+// Whether an environment is selected, rather than the More or Back tab or nothing at all.
+func (e *Ecosystem) hasSelectedEnv() bool {
+	return e.selectedEnv >= 0 && e.selectedEnv < len(e.environments)
+}
+
 func (e *Ecosystem) currentEnv() *Environment {
 	return &e.environments[e.selectedEnv]
 }
 
+// The log target for whatever the user has selected, or empty if that's
+// not an environment or machine.
+func (e *Ecosystem) currentLogTarget() string {
+	if !e.hasSelectedEnv() {
+		return ""
+	}
+	if e.currentEnv().hasFocus {
+		return e.currentEnv().logTarget()
+	}
+	if machine, err := e.currentMachine(); err == nil {
+		return machine.logTarget()
+	}
+	return ""
+}
+
 func (e *Ecosystem) incrementEnv() {
 	start, end := e.envPager.pg.GetSliceBounds(len(e.environments))
 	if e.envPager.moreIsSelected {
 		if e.envPager.pg.Page > 0 {
 			// There's a Back button to worry about
+			e.envPager.moreIsSelected = false
 			e.envPager.backIsSelected = true
 			e.selectedEnv = -1
 		} else {
@@ -155,13 +316,13 @@ func (e *Ecosystem) incrementEnv() {
 			// At the end, no More tab, so wrap around to Back tab
 			e.selectedEnv = -1
 			e.envPager.backIsSelected = true
-		} else if e.selectedEnv == len(e.environments[start:end])-1 && e.envPager.hasMultiplePages() {
+		} else if e.selectedEnv == end-1 && e.envPager.hasMultiplePages() {
 			// User selected the More tab
 			e.envPager.moreIsSelected = true
 			e.selectedEnv = -1
 		} else {
 			if e.selectedEnv == end-1 {
-				e.selectedEnv = 0
+				e.selectedEnv = start
 			} else {
 				e.selectedEnv += 1
 			}
@@ -190,9 +351,14 @@ func (e *Ecosystem) decrementEnv() {
 			e.envPager.moreIsSelected = false
 			e.selectedEnv = end - 1
 		} else if e.envPager.backIsSelected {
-			// Wrap around to end of env tabs (there is no More tab)
+			// Wrap around to the More tab, or the end of env tabs if there isn't one
 			e.envPager.backIsSelected = false
-			e.selectedEnv = end - 1
+			if e.envPager.pg.OnLastPage() {
+				e.selectedEnv = end - 1
+			} else {
+				e.envPager.moreIsSelected = true
+				e.selectedEnv = -1
+			}
 		} else {
 			e.selectedEnv -= 1
 		}
@@ -203,6 +369,9 @@ func (e *Ecosystem) View() (result string) {
 	if e.environments == nil {
 		return lipgloss.NewStyle().Foreground(textColor).Italic(true).Faint(true).Render("Still looking for environments...")
 	}
+	if len(e.environments) == 0 {
+		return lipgloss.NewStyle().Foreground(textColor).Italic(true).Faint(true).Render("Nothing matches " + e.filter)
+	}
 
 	// Create the tab headers, one for each environment.
 	var tabs []string
@@ -210,10 +379,7 @@ func (e *Ecosystem) View() (result string) {
 	for i, env := range e.environments[start:end] {
 		// Figure out which "tab" is selected and stylize accordingly
 		var style lipgloss.Style
-		idx := i
-		if e.envPager.pg.Page > 0 {
-			idx = i + e.envPager.pg.PerPage
-		}
+		idx := start + i
 		isFirst, _, isActive := idx == start, idx == len(e.environments)-1, idx == e.selectedEnv
 		if isActive {
 			style = activeTabStyle
@@ -231,7 +397,7 @@ func (e *Ecosystem) View() (result string) {
 		}
 
 		style = style.Border(border)
-		tabs = append(tabs, zone.Mark(env.name, style.Render(env.name)))
+		tabs = append(tabs, zone.Mark(env.zoneID(), style.Render(env.name)))
 	}
 
 	var tabContent string
@@ -281,7 +447,7 @@ func (e *Ecosystem) View() (result string) {
 		selectedEnv := e.environments[e.selectedEnv]
 		for i, machine := range selectedEnv.machines {
 			// "Viewing" a machine will get it's specific info
-			machineView := machine.View()
+			machineView := machine.View(e.isMarked(&machine))
 			commands := e.machineCommands.View(machine.selectedCommand, !selectedEnv.hasFocus)
 			cardInfo := lipgloss.JoinHorizontal(lipgloss.Center, machineView, commands)
 			if !selectedEnv.hasFocus && i == e.selectedMachine {
@@ -299,8 +465,9 @@ func (e *Ecosystem) View() (result string) {
 			envTitle = selectedEnvCardStyle.Render(selectedEnv.name)
 		}
 		envCard := lipgloss.JoinHorizontal(lipgloss.Center, envTitle, envCommands.View(selectedEnv.selectedCommand, selectedEnv.hasFocus))
+		envHome := envHomeStyle.Render(selectedEnv.home)
 
-		tabContent = envCard + "\n" + strings.Join(machineCards, "\n")
+		tabContent = envCard + "\n" + envHome + "\n" + strings.Join(machineCards, "\n")
 	}
 
 	tabHeader := lipgloss.JoinHorizontal(lipgloss.Top, tabs...)
@@ -321,14 +488,25 @@ func (e *Ecosystem) View() (result string) {
 
 // Environment represents a single Vagrant project
 type Environment struct {
-	// Friendly name for the Environment
+	// Friendly name for the Environment, the shortest suffix of home that's unique in the Ecosystem.
 	name string
 	// Environments have 0 or more machines
 	machines []Machine
 	// The currently selected command to run on the machine.
 	selectedCommand int
-	home            string
-	hasFocus        bool
+	// Absolute path to the Vagrant project. This is what identifies the Environment.
+	home     string
+	hasFocus bool
+}
+
+// Mouse zone for the environment's tab
+func (e *Environment) zoneID() string {
+	return "env:" + e.home
+}
+
+// Key used to find the command output for this environment
+func (e *Environment) logTarget() string {
+	return e.home
 }
 
 // Machine contains all the data and actions associated with a specific Machine
@@ -342,18 +520,38 @@ type Machine struct {
 	selectedCommand int
 }
 
-func (m *Machine) View() string {
+// Whether other is the same machine, which may be newer.
+func (m *Machine) sameAs(other Machine) bool {
+	if m.machineID != "" || other.machineID != "" {
+		return m.machineID == other.machineID
+	}
+	return m.home == other.home && m.name == other.name
+}
+
+// Key used to find the command output for this machine
+func (m *Machine) logTarget() string {
+	if m.machineID != "" {
+		return m.machineID
+	}
+	return filepath.Join(m.home, m.name)
+}
+
+func (m *Machine) View(marked bool) string {
 	displayName := m.name
 	// If there's no name yet, at least show the machineID
 	if displayName == "" {
 		displayName = m.machineID
 	}
+	title := cardTitleStyle.Render(displayName)
+	if marked {
+		title = cardTitleStyle.Render(markStyle.Render("● ") + displayName)
+	}
 
 	// Join the machine info for the card view
 	content := lipgloss.JoinVertical(
 		lipgloss.Right,
-		cardTitleStyle.Render(displayName),
-		cardStatusStyle.Foreground(statusColors[m.state]).Render(m.state),
+		title,
+		cardStatusStyle.Foreground(statusColor(m.state)).Render(m.state),
 		cardProviderStyle.Render(m.provider),
 	)
 
@@ -0,0 +1,154 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/braheezy/violet/pkg/vagrant"
+	"github.com/braheezy/violet/pkg/vagrant/vagranttest"
+	"github.com/charmbracelet/bubbles/paginator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// An ecosystem with an environment per home, each with a machine per ID.
+func testEcosystem(homes map[string][]string, order ...string) Ecosystem {
+	var environments []Environment
+	for _, home := range order {
+		env := Environment{name: home, home: home, hasFocus: true}
+		for _, id := range homes[home] {
+			env.machines = append(env.machines, Machine{machineID: id, home: home, state: "running"})
+		}
+		environments = append(environments, env)
+	}
+	pager := paginator.New()
+	pager.PerPage = 5
+	pager.SetTotalPages(len(environments))
+	return Ecosystem{environments: environments, envPager: environmentPager{pg: pager}}
+}
+
+func TestEcosystemRefresh(t *testing.T) {
+	eco := testEcosystem(map[string][]string{"/a": {"a1"}, "/b": {"b1", "b2"}}, "/a", "/b")
+	eco.selectedEnv = 1
+	eco.selectedMachine = 1
+	eco.environments[1].hasFocus = false
+	eco.environments[1].selectedCommand = 3
+	eco.environments[1].machines[1].name = "db"
+	eco.environments[1].machines[1].selectedCommand = 2
+
+	// A new environment shows up in front and a machine goes away
+	fresh := testEcosystem(map[string][]string{"/new": {"n1"}, "/a": {"a1"}, "/b": {"b2"}}, "/new", "/a", "/b")
+	fresh.environments[2].machines[0].state = "poweroff"
+	eco.refresh(fresh)
+
+	assert.Len(t, eco.environments, 3)
+	assert.Equal(t, 2, eco.selectedEnv, "Verify the selected environment is followed")
+	assert.Equal(t, 0, eco.selectedMachine, "Verify the selected machine is followed")
+	env := eco.currentEnv()
+	assert.False(t, env.hasFocus)
+	assert.Equal(t, 3, env.selectedCommand)
+	assert.Equal(t, "db", env.machines[0].name, "Verify names are kept")
+	assert.Equal(t, 2, env.machines[0].selectedCommand)
+	assert.Equal(t, "poweroff", env.machines[0].state, "Verify state is refreshed")
+	assert.True(t, eco.environments[0].hasFocus)
+
+	// Machines show up in front of the selected one
+	eco.refresh(testEcosystem(map[string][]string{"/new": {"n1"}, "/a": {"a1"}, "/b": {"b0", "b1", "b2"}}, "/new", "/a", "/b"))
+	machine, err := eco.currentMachine()
+	require.NoError(t, err)
+	assert.Equal(t, "b2", machine.machineID, "Verify the selected machine is followed, not its place")
+
+	// The selected environment goes away
+	eco.refresh(testEcosystem(map[string][]string{"/new": {"n1"}}, "/new"))
+	assert.Equal(t, 0, eco.selectedEnv)
+}
+
+func TestCreateEcosystem(t *testing.T) {
+	dir := t.TempDir()
+	for _, env := range []string{"foo/env1", "bar/env1"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, env), 0o755))
+	}
+	globalStatus := fmt.Sprintf(`1,,machine-id,aaa1111
+		1,,provider-name,libvirt
+		1,,machine-home,%[1]v/foo/env1
+		1,,state,running
+		1,,machine-id,bbb2222
+		1,,provider-name,docker
+		1,,machine-home,%[1]v/bar/env1
+		1,,state,not_created
+		1,,ui,info,---
+		1,,ui,info,aaa1111
+		1,,ui,info,web
+		1,,ui,info,libvirt
+		1,,ui,info,running
+		1,,ui,info,%[1]v/foo/env1
+		1,,ui,info,
+		1,,ui,info,bbb2222
+		1,,ui,info,db
+		1,,ui,info,docker
+		1,,ui,info,not_created
+		1,,ui,info,%[1]v/bar/env1`, filepath.ToSlash(dir))
+	machineIndex := fmt.Sprintf(`{"version": 1, "machines": {
+		"aaa1111e2c8": {"name": "web", "provider": "libvirt", "state": "running", "vagrantfile_path": %q},
+		"bbb2222d9f0": {"name": "db", "provider": "docker", "state": "not_created", "vagrantfile_path": %q}
+	}}`, filepath.Join(dir, "foo", "env1"), filepath.Join(dir, "bar", "env1"))
+
+	tests := []struct {
+		name   string
+		script vagranttest.Script
+	}{
+		{
+			name: "Verify ecosystem from global-status",
+			script: vagranttest.Script{Commands: []vagranttest.Command{
+				{Args: []string{"global-status", "--prune", "--machine-readable"}, Output: globalStatus},
+			}},
+		},
+		{
+			name:   "Verify ecosystem from the machine index",
+			script: vagranttest.Script{MachineIndex: machineIndex},
+		},
+	}
+
+	for _, test := range tests {
+		fake := vagranttest.New(t, test.script)
+		eco, err := createEcosystem(&vagrant.VagrantClient{ExecPath: fake.ExecPath, Env: fake.Env()})
+		require.NoError(t, err, test.name)
+
+		var names, labels, states []string
+		for _, env := range eco.environments {
+			labels = append(labels, env.name)
+			for _, machine := range env.machines {
+				names = append(names, machine.name)
+				states = append(states, machine.state)
+			}
+		}
+		assert.ElementsMatch(t, []string{"foo/env1", "bar/env1"}, labels, test.name)
+		assert.ElementsMatch(t, []string{"web", "db"}, names, test.name)
+		assert.ElementsMatch(t, []string{"running", "not created"}, states, test.name)
+		if test.script.MachineIndex != "" {
+			assert.Empty(t, fake.Calls(t), "Verify Vagrant isn't run when the index can be read")
+		}
+	}
+}
+
+func TestSSHCommand(t *testing.T) {
+	client := &vagrant.VagrantClient{ExecPath: "/opt/vagrant/bin/vagrant", Env: []string{"VAGRANT_HOME=/data/vagrant"}}
+	eco := Ecosystem{client: client}
+
+	cmd, err := eco.sshCommand(&Machine{machineID: "1a2", name: "web", home: "/envs/site", provider: "virtualbox"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/opt/vagrant/bin/vagrant", "ssh", "1a2"}, cmd.Args, "Verify the configured Vagrant is used")
+	assert.Equal(t, client.Env, cmd.Env)
+
+	cmd, err = eco.sshCommand(&Machine{machineID: "3b4", name: "app", home: "/envs/site", provider: "docker"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/opt/vagrant/bin/vagrant", "docker-exec", "app", "-it", "--", "/bin/sh"}, cmd.Args)
+	assert.Equal(t, "/envs/site", cmd.Dir)
+	assert.Equal(t, client.Env, cmd.Env)
+
+	eco.client = vagrant.NewSimulator()
+	_, err = eco.sshCommand(&Machine{machineID: "1a2"})
+	assert.Error(t, err)
+}
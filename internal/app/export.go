@@ -0,0 +1,158 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/braheezy/violet/internal/inventory"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Where things are exported to, unless the environment says otherwise.
+// The SSH config is ~/.ssh/config.
+const (
+	defaultExportPath  = "violet-inventory.json"
+	defaultAnsiblePath = "violet-ansible.ini"
+)
+
+// exportMsg is emitted when something has been written to a file.
+type exportMsg struct {
+	// What was exported, for people
+	what string
+	path string
+	err  error
+}
+
+// Where to export the inventory, from VIOLET_EXPORT if it's set. The extension picks the format.
+func exportPath() string {
+	if path := os.Getenv("VIOLET_EXPORT"); path != "" {
+		return path
+	}
+	return defaultExportPath
+}
+
+// Where to write the Ansible inventory, from VIOLET_ANSIBLE_INVENTORY if it's set.
+// It's INI unless the extension says it's YAML.
+func ansiblePath() string {
+	if path := os.Getenv("VIOLET_ANSIBLE_INVENTORY"); path != "" {
+		return path
+	}
+	return defaultAnsiblePath
+}
+
+// Which SSH config to update, from VIOLET_SSH_CONFIG if it's set.
+func sshConfigPath() (string, error) {
+	if path := os.Getenv("VIOLET_SSH_CONFIG"); path != "" {
+		return path, nil
+	}
+	return inventory.DefaultSSHConfigPath()
+}
+
+// Everything the ecosystem knows, in the form the CLI prints it in.
+func (e *Ecosystem) inventory() []inventory.Environment {
+	environments := []inventory.Environment{}
+	// Everything, whatever the filter hides
+	for _, env := range e.everything() {
+		environment := inventory.Environment{Name: env.name, Home: env.home, Machines: []inventory.Machine{}}
+		for _, machine := range env.machines {
+			environment.Machines = append(environment.Machines, inventory.Machine{
+				ID:       machine.machineID,
+				Name:     machine.name,
+				Provider: machine.provider,
+				// Shown with spaces, but exported the way Vagrant and the CLI spell it
+				State: strings.ReplaceAll(machine.state, " ", "_"),
+				Home:  machine.home,
+			})
+		}
+		environments = append(environments, environment)
+	}
+	return environments
+}
+
+// Create the tea.Cmd that writes the inventory to the export file.
+func (v *Violet) createExportCmd() tea.Cmd {
+	environments := v.ecosystem.inventory()
+	path := exportPath()
+	return func() tea.Msg {
+		err := writeFile(path, func(w io.Writer) error {
+			return inventory.Write(w, inventory.FormatOf(path), environments)
+		})
+		return exportMsg{what: "the inventory", path: path, err: err}
+	}
+}
+
+// Create the tea.Cmd that asks Vagrant how to connect to each running machine
+// and writes an Ansible inventory of them.
+func (v *Violet) createAnsibleExportCmd() tea.Cmd {
+	environments := v.ecosystem.inventory()
+	client := v.ecosystem.client
+	path := ansiblePath()
+	format := "ini"
+	if inventory.FormatOf(path) == "yaml" {
+		format = "yaml"
+	}
+	return func() tea.Msg {
+		msg := exportMsg{what: "the Ansible inventory", path: path}
+		if msg.err = inventory.LoadSSH(context.Background(), client, environments); msg.err != nil {
+			return msg
+		}
+		msg.err = writeFile(path, func(w io.Writer) error {
+			return inventory.WriteAnsible(w, format, environments)
+		})
+		return msg
+	}
+}
+
+// Ask before touching the user's SSH config, then update violet's block in it.
+func (v *Violet) confirmSSHConfigUpdate() {
+	path, err := sshConfigPath()
+	if err != nil {
+		v.setErrorMessage(fmt.Sprintf("Couldn't find the SSH config: %v", err))
+		return
+	}
+	v.confirm = newConfirmDialog(
+		fmt.Sprintf("Update violet's block in %v with the running machines?", path),
+		"",
+		func(v *Violet) tea.Cmd { return v.createSSHConfigUpdateCmd(path) },
+	)
+}
+
+// Create the tea.Cmd that asks Vagrant how to connect to each running machine
+// and writes them to violet's block of the SSH config at path.
+func (v *Violet) createSSHConfigUpdateCmd(path string) tea.Cmd {
+	environments := v.ecosystem.inventory()
+	client := v.ecosystem.client
+	return func() tea.Msg {
+		msg := exportMsg{what: "the SSH config of the running machines", path: path}
+		if msg.err = inventory.LoadSSH(context.Background(), client, environments); msg.err != nil {
+			return msg
+		}
+		msg.err = inventory.UpdateSSHConfig(path, environments)
+		return msg
+	}
+}
+
+// Create or replace the file at path with what write writes.
+func writeFile(path string, write func(w io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = write(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Tell the user how the export went.
+func (v *Violet) finishExport(msg exportMsg) {
+	if msg.err != nil {
+		v.setErrorMessage(fmt.Sprintf("Couldn't export %v: %v", msg.what, msg.err))
+		return
+	}
+	v.notice = fmt.Sprintf("Exported %v to %v", msg.what, msg.path)
+}
@@ -0,0 +1,95 @@
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/braheezy/violet/internal/inventory"
+	"github.com/braheezy/violet/pkg/vagrant"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestExport(t *testing.T) {
+	eco, err := createEcosystem(vagrant.NewSimulator(
+		vagrant.SimulatedMachine{ID: "1a2b3c4", Name: "web", Provider: "virtualbox", State: "not_created", Home: "/projects/site"},
+	))
+	require.NoError(t, err)
+	v := press(newViolet(vagrant.NewSimulator()), ecosystemMsg(eco))
+	expected := []inventory.Environment{{
+		Name: "site",
+		Home: "/projects/site",
+		Machines: []inventory.Machine{
+			{ID: "1a2b3c4", Name: "web", Provider: "virtualbox", State: "not_created", Home: "/projects/site"},
+		},
+	}}
+
+	path := filepath.Join(t.TempDir(), "machines.json")
+	t.Setenv("VIOLET_EXPORT", path)
+	v = press(v, v.createExportCmd()())
+	assert.Equal(t, "Exported the inventory to "+path, v.notice)
+	var environments []inventory.Environment
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &environments))
+	assert.Equal(t, expected, environments)
+
+	path = filepath.Join(t.TempDir(), "machines.yml")
+	t.Setenv("VIOLET_EXPORT", path)
+	press(v, v.createExportCmd()())
+	environments = nil
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, yaml.Unmarshal(data, &environments), "Verify the extension picks the format")
+	assert.Equal(t, expected, environments)
+
+	t.Setenv("VIOLET_EXPORT", filepath.Join(t.TempDir(), "missing", "machines.json"))
+	v = press(v, v.createExportCmd()())
+	assert.Contains(t, v.errorMessage, "Couldn't export the inventory")
+}
+
+func TestAnsibleExport(t *testing.T) {
+	sim := vagrant.NewSimulator(
+		vagrant.SimulatedMachine{ID: "1a2b3c4", Name: "web", Provider: "virtualbox", State: "running", Home: "/projects/site"},
+		vagrant.SimulatedMachine{ID: "5d6e7f8", Name: "db", Provider: "virtualbox", State: "poweroff", Home: "/projects/site"},
+	)
+	eco, err := createEcosystem(sim)
+	require.NoError(t, err)
+	v := press(newViolet(sim), ecosystemMsg(eco))
+
+	path := filepath.Join(t.TempDir(), "hosts.yaml")
+	t.Setenv("VIOLET_ANSIBLE_INVENTORY", path)
+	v = press(v, v.createAnsibleExportCmd()())
+	assert.Equal(t, "Exported the Ansible inventory to "+path, v.notice)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "site-web:")
+	assert.NotContains(t, string(data), "site-db", "Verify machines that aren't running are left out")
+}
+
+func TestSSHConfigUpdate(t *testing.T) {
+	sim := vagrant.NewSimulator(
+		vagrant.SimulatedMachine{ID: "1a2b3c4", Name: "web", Provider: "virtualbox", State: "running", Home: "/projects/site"},
+	)
+	eco, err := createEcosystem(sim)
+	require.NoError(t, err)
+	v := press(newViolet(sim), ecosystemMsg(eco))
+
+	path := filepath.Join(t.TempDir(), "config")
+	t.Setenv("VIOLET_SSH_CONFIG", path)
+	v = press(v, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("w")})
+	require.NotNil(t, v.confirm, "Verify the SSH config isn't touched without asking")
+	assert.Contains(t, v.confirm.prompt, path)
+
+	model, cmd := v.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	require.NotNil(t, cmd)
+	v = press(model.(Violet), cmd())
+	assert.Equal(t, "Exported the SSH config of the running machines to "+path, v.notice)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "Host site-web\n")
+}
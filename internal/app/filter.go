@@ -0,0 +1,220 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/braheezy/violet/internal/config"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Keys used while typing a filter.
+type filterKeyMap struct {
+	Apply key.Binding
+	Clear key.Binding
+}
+
+var filterKeys = newFilterKeyMap(config.DefaultKeys())
+
+func newFilterKeyMap(k config.Keys) filterKeyMap {
+	return filterKeyMap{
+		Apply: newBinding(k, "jump to match", "filter_apply"),
+		Clear: newBinding(k, "clear", "filter_clear"),
+	}
+}
+
+// Fields a filter can match exactly, instead of fuzzily e.g. state:running
+var filterFields = []string{"env", "machine", "provider", "state"}
+
+// A filter, parsed.
+type filterQuery struct {
+	// Each fuzzily matches an environment's name or home, or a machine's name, provider or state
+	terms []string
+	// The values for each field, any of which may match e.g. state:running state:saved
+	fields map[string][]string
+}
+
+func parseFilter(query string) filterQuery {
+	q := filterQuery{fields: make(map[string][]string)}
+	for _, word := range strings.Fields(query) {
+		field, value, ok := strings.Cut(word, ":")
+		if ok && slices.Contains(filterFields, field) {
+			if value != "" {
+				q.fields[field] = append(q.fields[field], value)
+			}
+			continue
+		}
+		q.terms = append(q.terms, word)
+	}
+	return q
+}
+
+// Whether s contains any of values, ignoring case and whether words are split by spaces or underscores.
+func containsAny(s string, values []string) bool {
+	normalize := func(s string) string { return strings.ToLower(strings.ReplaceAll(s, "_", " ")) }
+	for _, value := range values {
+		if strings.Contains(normalize(s), normalize(value)) {
+			return true
+		}
+	}
+	return false
+}
+
+// How well every term matches one of texts, and whether they all do.
+func (q filterQuery) score(texts ...string) (int, bool) {
+	total := 0
+	for _, term := range q.terms {
+		best, found := 0, false
+		for _, text := range texts {
+			if score, ok := fuzzyScore(term, text); ok {
+				best, found = max(best, score), true
+			}
+		}
+		if !found {
+			return 0, false
+		}
+		total += best
+	}
+	return total, true
+}
+
+// Score a machine in env, and whether it matches at all.
+func (q filterQuery) scoreMachine(env Environment, m Machine) (int, bool) {
+	if values := q.fields["machine"]; values != nil && !containsAny(m.name, values) {
+		return 0, false
+	}
+	if values := q.fields["provider"]; values != nil && !containsAny(m.provider, values) {
+		return 0, false
+	}
+	if values := q.fields["state"]; values != nil && !containsAny(m.state, values) {
+		return 0, false
+	}
+	return q.score(env.name, env.home, m.name, m.provider, m.state)
+}
+
+// The environments that match query, best first, with only their machines that match.
+func filterEnvironments(environments []Environment, query string) []Environment {
+	q := parseFilter(query)
+	type match struct {
+		env   Environment
+		score int
+	}
+	var matches []match
+	for _, env := range environments {
+		if values := q.fields["env"]; values != nil && !containsAny(env.name, values) && !containsAny(env.home, values) {
+			continue
+		}
+		m := match{env: env}
+		m.env.machines = nil
+		for _, machine := range env.machines {
+			if score, ok := q.scoreMachine(env, machine); ok {
+				m.env.machines = append(m.env.machines, machine)
+				m.score = max(m.score, score)
+			}
+		}
+		if len(env.machines) == 0 && q.fields["machine"] == nil && q.fields["provider"] == nil && q.fields["state"] == nil {
+			// Nothing to match but the environment itself
+			score, ok := q.score(env.name, env.home)
+			if ok {
+				matches = append(matches, match{env: env, score: score})
+			}
+			continue
+		}
+		if len(m.env.machines) > 0 {
+			matches = append(matches, m)
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b match) int { return b.score - a.score })
+
+	filtered := make([]Environment, len(matches))
+	for i, m := range matches {
+		filtered[i] = m.env
+	}
+	return filtered
+}
+
+// How well pattern matches s, where every character of pattern has to appear in s in order,
+// ignoring case. Characters that follow each other, or start words, score more.
+func fuzzyScore(pattern, s string) (int, bool) {
+	p := []rune(strings.ToLower(pattern))
+	score, matched := 0, 0
+	consecutive := false
+	var previous rune
+	for i, r := range []rune(strings.ToLower(s)) {
+		if matched < len(p) && r == p[matched] {
+			score++
+			if consecutive {
+				score += 2
+			}
+			if i == 0 || strings.ContainsRune("/-_. ", previous) {
+				score += 3
+			}
+			matched++
+			consecutive = true
+		} else {
+			consecutive = false
+		}
+		previous = r
+	}
+	return score, matched == len(p)
+}
+
+// Start typing a filter, where the last one left off.
+func (v *Violet) openFilter() tea.Cmd {
+	v.filtering = true
+	v.filterInput.PromptStyle = panelTitleStyle
+	v.filterInput.TextStyle = confirmPromptStyle
+	v.filterInput.CursorEnd()
+	return v.filterInput.Focus()
+}
+
+// Handle a key press while typing a filter, narrowing what's shown as it changes.
+func (v *Violet) updateFilter(msg tea.KeyMsg) tea.Cmd {
+	switch {
+	case key.Matches(msg, filterKeys.Apply):
+		v.filtering = false
+		v.filterInput.Blur()
+		return nil
+	case key.Matches(msg, filterKeys.Clear):
+		v.filtering = false
+		v.filterInput.Blur()
+		v.filterInput.Reset()
+		v.ecosystem.setFilter("")
+		return nil
+	// Move between matches, unless the keys are for typing
+	case msg.Type != tea.KeyRunes && key.Matches(msg, v.keys.Tab):
+		v.ecosystem.incrementEnv()
+		return nil
+	case msg.Type != tea.KeyRunes && key.Matches(msg, v.keys.ShiftTab):
+		v.ecosystem.decrementEnv()
+		return nil
+	}
+	var cmd tea.Cmd
+	v.filterInput, cmd = v.filterInput.Update(msg)
+	v.ecosystem.setFilter(v.filterInput.Value())
+	return cmd
+}
+
+// The filter being typed, or the one in use.
+func (v *Violet) filterView() string {
+	var parts []string
+	switch {
+	case v.filtering:
+		parts = append(parts, v.filterInput.View())
+	case v.ecosystem.filter != "":
+		parts = append(parts, panelTitleStyle.Render("Filter: ")+confirmPromptStyle.Render(v.ecosystem.filter))
+	default:
+		return ""
+	}
+	if v.ecosystem.filter != "" {
+		parts = append(parts, panelHintStyle.Render(fmt.Sprintf("%v of %v environments", len(v.ecosystem.environments), len(v.ecosystem.unfiltered))))
+	}
+	if v.filtering {
+		parts = append(parts, panelHintStyle.Render(helpLine(filterKeys.Apply, filterKeys.Clear)))
+	} else {
+		parts = append(parts, panelHintStyle.Render(helpLine(v.keys.Filter)))
+	}
+	return strings.Join(parts, "  ")
+}
@@ -0,0 +1,129 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/braheezy/violet/pkg/vagrant"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterEnvironments(t *testing.T) {
+	environments := []Environment{
+		{name: "site", home: "/projects/site", machines: []Machine{
+			{name: "web", provider: "virtualbox", state: "running"},
+			{name: "db", provider: "virtualbox", state: "poweroff"},
+		}},
+		{name: "tool", home: "/work/tool", machines: []Machine{
+			{name: "default", provider: "libvirt", state: "not created"},
+		}},
+		{name: "website", home: "/projects/website", machines: []Machine{
+			{name: "default", provider: "libvirt", state: "running"},
+		}},
+		{name: "empty", home: "/work/empty"},
+	}
+	tests := []struct {
+		name     string
+		query    string
+		expected map[string][]string
+		order    []string
+	}{
+		{"Verify environment names fuzzy match", "wbst", map[string][]string{"website": {"default"}}, nil},
+		{"Verify machine names narrow the machines", "db", map[string][]string{"site": {"db"}}, nil},
+		{"Verify homes match", "work/", map[string][]string{"tool": {"default"}, "empty": nil}, nil},
+		{"Verify the best match comes first", "site", nil, []string{"site", "website"}},
+		{"Verify state filters", "state:running", map[string][]string{"site": {"web"}, "website": {"default"}}, nil},
+		{"Verify state filters can use underscores", "state:not_created", map[string][]string{"tool": {"default"}}, nil},
+		{"Verify filters combine", "state:running provider:libvirt", map[string][]string{"website": {"default"}}, nil},
+		{"Verify a field can have several values", "state:poweroff state:not", map[string][]string{"site": {"db"}, "tool": {"default"}}, nil},
+		{"Verify filters and fuzzy terms combine", "state:running web", map[string][]string{"site": {"web"}, "website": {"default"}}, nil},
+		{"Verify environment filters", "env:tool", map[string][]string{"tool": {"default"}}, nil},
+		{"Verify nothing matching", "zzz", map[string][]string{}, nil},
+	}
+	for _, test := range tests {
+		filtered := filterEnvironments(environments, test.query)
+		require.NotNil(t, filtered, test.name)
+		var names []string
+		for _, env := range filtered {
+			names = append(names, env.name)
+		}
+		if test.order != nil {
+			assert.Equal(t, test.order, names, test.name)
+			continue
+		}
+		matched := make(map[string][]string)
+		for _, env := range filtered {
+			var machines []string
+			for _, machine := range env.machines {
+				machines = append(machines, machine.name)
+			}
+			matched[env.name] = machines
+		}
+		assert.Equal(t, test.expected, matched, test.name)
+	}
+	assert.Len(t, environments[0].machines, 2, "Verify the environments aren't changed")
+}
+
+func TestFuzzyScore(t *testing.T) {
+	_, ok := fuzzyScore("wb", "web")
+	assert.True(t, ok)
+	_, ok = fuzzyScore("bw", "web")
+	assert.False(t, ok, "Verify order matters")
+	prefix, _ := fuzzyScore("we", "website")
+	scattered, _ := fuzzyScore("we", "hardware")
+	assert.Greater(t, prefix, scattered)
+}
+
+// Type text into whatever has the focus.
+func typeText(v Violet, text string) Violet {
+	for _, r := range text {
+		v = press(v, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	return v
+}
+
+func TestFilter(t *testing.T) {
+	eco, err := createEcosystem(newTestEcosystem(12))
+	require.NoError(t, err)
+	v := press(newViolet(vagrant.NewSimulator()), ecosystemMsg(eco))
+	slash := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("/")}
+
+	v = typeText(press(v, slash), "app11")
+	assert.True(t, v.filtering)
+	assert.Equal(t, "app11", selectedTab(v.ecosystem), "Verify it jumps straight to the match")
+	assert.Len(t, v.ecosystem.environments, 1)
+	assert.Contains(t, v.View(), "1 of 12 environments")
+
+	v = typeText(press(v, tea.KeyMsg{Type: tea.KeyBackspace}), " state:poweroff")
+	assert.Len(t, v.ecosystem.environments, 4, "Verify app10, app11, app12 and fuzzily app01 match")
+	assert.Len(t, v.ecosystem.currentEnv().machines, 1)
+	v = press(v, tab)
+	assert.Equal(t, "app11", selectedTab(v.ecosystem), "Verify Tab moves between matches")
+
+	v = press(v, enter)
+	assert.False(t, v.filtering)
+	assert.Equal(t, "app1 state:poweroff", v.ecosystem.filter, "Verify the filter stays")
+	v = press(v, right)
+	assert.Equal(t, 1, v.ecosystem.currentEnv().selectedCommand, "Verify the keys work on matches")
+
+	// A machine the filter hides changes
+	v = press(v, machineStatusMsg{identifier: "a010000", status: vagrant.MachineInfo{Name: "web", Fields: map[string]string{"state": "poweroff", "provider-name": "virtualbox"}}})
+	assert.Len(t, v.ecosystem.environments[3].machines, 2, "Verify machines that start matching are shown")
+
+	v = press(v, slash, tea.KeyMsg{Type: tea.KeyEsc})
+	assert.False(t, v.filtering)
+	assert.Empty(t, v.ecosystem.filter)
+	assert.Len(t, v.ecosystem.environments, 12)
+	assert.Equal(t, "app11", selectedTab(v.ecosystem), "Verify the selection is kept")
+	assert.Equal(t, 2, v.ecosystem.envPager.pg.Page)
+	assert.Equal(t, 1, v.ecosystem.currentEnv().selectedCommand, "Verify what was done to matches is kept")
+	assert.Len(t, v.ecosystem.currentEnv().machines, 2)
+	assert.Equal(t, "poweroff", v.ecosystem.environments[0].machines[0].state)
+
+	v = typeText(press(v, slash), "zzz")
+	assert.False(t, v.ecosystem.hasSelectedEnv())
+	assert.Contains(t, v.View(), "Nothing matches zzz")
+	v = press(v, tab, down, right, enter)
+	assert.Empty(t, v.errorMessage, "Verify keys don't need a match")
+}
@@ -0,0 +1,335 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/braheezy/violet/internal/config"
+	"github.com/braheezy/violet/pkg/vagrant"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// How many jobs the jobs panel lists, most recent last.
+const maxShownJobs = 5
+
+// How many finished jobs are kept, output and all, before the oldest are forgotten.
+const maxFinishedJobs = 50
+
+type jobStatus int
+
+const (
+	jobQueued jobStatus = iota
+	jobRunning
+	jobSucceeded
+	jobFailed
+	jobCancelled
+)
+
+// jobTarget is what a job runs against, either a whole environment or one of its machines.
+type jobTarget struct {
+	// Home of the environment the job runs in
+	home string
+	// The machine the job runs on. Both are empty when the job is for the whole environment.
+	machineID   string
+	machineName string
+	// What to call the target in the UI
+	label string
+	// Key used to find the output of jobs for this target
+	key string
+}
+
+func envTarget(env *Environment) jobTarget {
+	return jobTarget{
+		home:  env.home,
+		label: env.name,
+		key:   env.logTarget(),
+	}
+}
+
+func machineTarget(env *Environment, machine *Machine) jobTarget {
+	label := machine.name
+	if label == "" {
+		label = machine.machineID
+	}
+	return jobTarget{
+		home:        env.home,
+		machineID:   machine.machineID,
+		machineName: machine.name,
+		label:       env.name + "/" + label,
+		key:         machine.logTarget(),
+	}
+}
+
+func (t jobTarget) isEnv() bool {
+	return t.machineID == "" && t.machineName == ""
+}
+
+// Vagrant locks machines while it works on them, so two jobs that touch the same
+// machine can't run at the same time.
+func (t jobTarget) conflictsWith(other jobTarget) bool {
+	return t.home == other.home && (t.isEnv() || other.isEnv() || t.key == other.key)
+}
+
+// A job is a Vagrant command running, or waiting to run, in the background.
+type job struct {
+	id      int
+	target  jobTarget
+	command string
+	// Starts the command
+	run      func(ctx context.Context) *vagrant.Stream
+	status   jobStatus
+	started  time.Time
+	finished time.Time
+	// Everything the command has printed so far
+	output []string
+	err    error
+	// The reason Vagrant gave for failing, if it did
+	vagrantError string
+	spinner      jobSpinner
+	cancel       context.CancelFunc
+	// Closed once the command has exited, or the job was cancelled before it started.
+	done chan struct{}
+}
+
+func (j *job) finish(err error) {
+	j.finished = time.Now()
+	j.err = err
+	switch {
+	case errors.Is(err, context.Canceled):
+		j.status = jobCancelled
+	case err != nil:
+		j.status = jobFailed
+	default:
+		j.status = jobSucceeded
+	}
+}
+
+func (j *job) View() string {
+	command := jobCommandStyle.Render(j.command)
+	switch j.status {
+	case jobQueued:
+		return fmt.Sprintf("%v %v %v %v", jobFaintStyle.Render("…"), command, j.target.label, jobFaintStyle.Render("queued"))
+	case jobRunning:
+		elapsed := time.Since(j.started).Round(time.Second)
+		title := spinnerStyle.Render(fmt.Sprintf("%v: %v command", j.target.label, j.spinner.verb))
+		return fmt.Sprintf("%v %v %v %v", j.spinner.spinner.View(), title, command, jobFaintStyle.Render(elapsed.String()))
+	case jobSucceeded:
+		elapsed := j.finished.Sub(j.started).Round(time.Second)
+		return fmt.Sprintf("%v %v %v %v", cardStatusStyle.Foreground(statusColor("running")).Render("✔"), command, j.target.label, jobFaintStyle.Render("finished in "+elapsed.String()))
+	case jobFailed:
+		return fmt.Sprintf("%v %v %v %v", errorTitleStyle.UnsetMargins().Render("✘"), command, j.target.label, errorStyle.UnsetMargins().Render(j.errorMessage()))
+	default:
+		return fmt.Sprintf("%v %v %v %v", jobFaintStyle.Render("⊘"), command, j.target.label, jobFaintStyle.Render("cancelled"))
+	}
+}
+
+// Best description of why a job failed
+func (j *job) errorMessage() string {
+	if j.vagrantError != "" {
+		return j.vagrantError
+	}
+	return j.err.Error()
+}
+
+// jobOutputMsg is emitted for each line of output from a running job.
+type jobOutputMsg struct {
+	id   int
+	line vagrant.OutputLine
+	// Where to get the next line from
+	stream *vagrant.Stream
+}
+
+// jobDoneMsg is emitted when the command of a job has exited.
+type jobDoneMsg struct {
+	id  int
+	err error
+}
+
+// jobManager runs Vagrant commands in the background, a limited number at a time.
+type jobManager struct {
+	jobs   []*job
+	nextID int
+	// Maximum number of jobs running at once
+	parallelism int
+}
+
+func newJobManager(parallelism int) jobManager {
+	if parallelism < 1 {
+		parallelism = config.Default().Parallelism
+	}
+	return jobManager{parallelism: parallelism}
+}
+
+// Queue a new job. Returns the tea.Cmd that starts it, if it can start now.
+func (jm *jobManager) submit(target jobTarget, command string, run func(ctx context.Context) *vagrant.Stream) tea.Cmd {
+	jm.nextID++
+	jm.jobs = append(jm.jobs, &job{
+		id:      jm.nextID,
+		target:  target,
+		command: command,
+		run:     run,
+		spinner: newJobSpinner(),
+		done:    make(chan struct{}),
+	})
+	return jm.startQueued()
+}
+
+// Start as many queued jobs as parallelism and machine locks allow.
+func (jm *jobManager) startQueued() tea.Cmd {
+	var cmds []tea.Cmd
+	for _, queued := range jm.jobs {
+		if queued.status != jobQueued || jm.running() >= jm.parallelism {
+			continue
+		}
+		blocked := false
+		for _, running := range jm.jobs {
+			if running.status == jobRunning && running.target.conflictsWith(queued.target) {
+				blocked = true
+				break
+			}
+		}
+		if !blocked {
+			cmds = append(cmds, jm.start(queued))
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+func (jm *jobManager) start(j *job) tea.Cmd {
+	ctx, cancel := context.WithCancel(context.Background())
+	j.cancel = cancel
+	j.status = jobRunning
+	j.started = time.Now()
+
+	id, run, done := j.id, j.run, j.done
+	startCmd := func() tea.Msg {
+		stream := run(ctx)
+		go func() {
+			stream.Wait()
+			close(done)
+		}()
+		return waitForJobOutput(id, stream)()
+	}
+	// The tick must be sent for the spinner to spin
+	return tea.Batch(startCmd, j.spinner.spinner.Tick)
+}
+
+// Create the tea.Cmd that waits for the next line of output from stream, or
+// the result of the command once the output is done.
+func waitForJobOutput(id int, stream *vagrant.Stream) tea.Cmd {
+	return func() tea.Msg {
+		if line, ok := <-stream.Lines; ok {
+			return jobOutputMsg{id: id, line: line, stream: stream}
+		}
+		return jobDoneMsg{id: id, err: stream.Wait()}
+	}
+}
+
+func (jm *jobManager) get(id int) *job {
+	for _, j := range jm.jobs {
+		if j.id == id {
+			return j
+		}
+	}
+	return nil
+}
+
+func (jm *jobManager) running() int {
+	count := 0
+	for _, j := range jm.jobs {
+		if j.status == jobRunning {
+			count++
+		}
+	}
+	return count
+}
+
+// The most recent job for the target with key, if any.
+func (jm *jobManager) latestFor(key string) *job {
+	for i := len(jm.jobs) - 1; i >= 0; i-- {
+		if jm.jobs[i].target.key == key {
+			return jm.jobs[i]
+		}
+	}
+	return nil
+}
+
+// Cancel every job for the target with key that hasn't finished yet.
+// Running commands are interrupted and given a chance to clean up before they are killed.
+func (jm *jobManager) cancelFor(key string) {
+	for _, j := range jm.jobs {
+		if j.target.key == key {
+			jm.cancel(j)
+		}
+	}
+}
+
+func (jm *jobManager) cancel(j *job) {
+	switch j.status {
+	case jobQueued:
+		j.finish(context.Canceled)
+		close(j.done)
+	case jobRunning:
+		j.cancel()
+	}
+}
+
+// Cancel every job and wait up to timeout for them to exit.
+func (jm *jobManager) stopAll(timeout time.Duration) {
+	deadline := time.After(timeout)
+	for _, j := range jm.jobs {
+		jm.cancel(j)
+	}
+	for _, j := range jm.jobs {
+		select {
+		case <-j.done:
+		case <-deadline:
+			return
+		}
+	}
+}
+
+// Forget the oldest finished jobs beyond maxFinishedJobs, except those keep says are still needed.
+func (jm *jobManager) prune(keep func(j *job) bool) {
+	finished := 0
+	for i := len(jm.jobs) - 1; i >= 0; i-- {
+		j := jm.jobs[i]
+		if j.status == jobQueued || j.status == jobRunning || keep(j) {
+			continue
+		}
+		finished++
+		if finished > maxFinishedJobs {
+			jm.jobs = slices.Delete(jm.jobs, i, i+1)
+		}
+	}
+}
+
+// Pass spinner ticks on to the jobs they belong to.
+func (jm *jobManager) updateSpinners(msg spinner.TickMsg) tea.Cmd {
+	for _, j := range jm.jobs {
+		if j.status == jobRunning && j.spinner.spinner.ID() == msg.ID {
+			var cmd tea.Cmd
+			j.spinner.spinner, cmd = j.spinner.spinner.Update(msg)
+			return cmd
+		}
+	}
+	return nil
+}
+
+func (jm *jobManager) View() string {
+	if len(jm.jobs) == 0 {
+		return ""
+	}
+	shown := jm.jobs[max(len(jm.jobs)-maxShownJobs, 0):]
+	var rows []string
+	for _, j := range shown {
+		rows = append(rows, j.View())
+	}
+	header := jobFaintStyle.Render(fmt.Sprintf("Jobs (%v running, %v total)", jm.running(), len(jm.jobs)))
+	return jobsPanelStyle.Render(header + "\n" + strings.Join(rows, "\n"))
+}
@@ -0,0 +1,97 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"github.com/braheezy/violet/pkg/vagrant"
+	"github.com/stretchr/testify/assert"
+)
+
+// Jobs in these tests are never actually run
+func noRun(ctx context.Context) *vagrant.Stream { return nil }
+
+func TestJobManager_Scheduling(t *testing.T) {
+	env1 := &Environment{name: "env1", home: "/foo/env1"}
+	env2 := &Environment{name: "env2", home: "/foo/env2"}
+	env3 := &Environment{name: "env3", home: "/foo/env3"}
+	web := &Machine{name: "web", machineID: "aaa1111", home: env1.home}
+	db := &Machine{name: "db", machineID: "bbb2222", home: env1.home}
+
+	t.Run("Verify parallelism is respected", func(t *testing.T) {
+		jm := newJobManager(2)
+		jm.submit(envTarget(env1), "up", noRun)
+		jm.submit(envTarget(env2), "up", noRun)
+		jm.submit(envTarget(env3), "up", noRun)
+
+		assert.Equal(t, jobRunning, jm.get(1).status)
+		assert.Equal(t, jobRunning, jm.get(2).status)
+		assert.Equal(t, jobQueued, jm.get(3).status)
+
+		jm.get(1).finish(nil)
+		jm.startQueued()
+		assert.Equal(t, jobRunning, jm.get(3).status)
+	})
+	t.Run("Verify jobs on the same machine wait for each other", func(t *testing.T) {
+		jm := newJobManager(4)
+		jm.submit(machineTarget(env1, web), "up", noRun)
+		jm.submit(machineTarget(env1, db), "up", noRun)
+		jm.submit(machineTarget(env1, web), "provision", noRun)
+		jm.submit(envTarget(env1), "halt", noRun)
+
+		assert.Equal(t, jobRunning, jm.get(1).status)
+		assert.Equal(t, jobRunning, jm.get(2).status, "Different machines can run together")
+		assert.Equal(t, jobQueued, jm.get(3).status)
+		assert.Equal(t, jobQueued, jm.get(4).status, "Environment jobs touch every machine")
+	})
+	t.Run("Verify cancelling a queued job", func(t *testing.T) {
+		jm := newJobManager(1)
+		jm.submit(envTarget(env1), "up", noRun)
+		jm.submit(envTarget(env2), "up", noRun)
+
+		jm.cancelFor(env2.logTarget())
+
+		assert.Equal(t, jobRunning, jm.get(1).status)
+		assert.Equal(t, jobCancelled, jm.get(2).status)
+		assert.Equal(t, jm.get(2), jm.latestFor(env2.logTarget()))
+	})
+}
+
+func TestJobManager_Prune(t *testing.T) {
+	env := &Environment{name: "env", home: "/foo/env"}
+	jm := newJobManager(1)
+	for range maxFinishedJobs + 3 {
+		jm.submit(envTarget(env), "up", noRun)
+	}
+	for _, j := range jm.jobs[:maxFinishedJobs+2] {
+		j.finish(nil)
+	}
+
+	jm.prune(func(j *job) bool { return j.id == 2 })
+	assert.Len(t, jm.jobs, maxFinishedJobs+2)
+	assert.Nil(t, jm.get(1), "Verify the oldest finished job is forgotten")
+	assert.NotNil(t, jm.get(2), "Verify jobs that are still needed are kept")
+	assert.NotNil(t, jm.get(maxFinishedJobs+3), "Verify unfinished jobs are kept")
+}
+
+func TestPruneJobs(t *testing.T) {
+	env := &Environment{name: "env", home: "/foo/env", hasFocus: true}
+	other := &Environment{name: "other", home: "/foo/other"}
+	v := newViolet(vagrant.NewSimulator())
+	v.ecosystem.environments = []Environment{*env}
+	v.jobs.submit(envTarget(env), "up", noRun)
+	v.jobs.submit(envTarget(other), "up", noRun)
+	for range maxFinishedJobs + 1 {
+		v.jobs.submit(envTarget(other), "halt", noRun)
+	}
+	for _, j := range v.jobs.jobs {
+		j.finish(nil)
+	}
+	v.bulkRuns = []*bulkRun{{command: "up", jobs: []int{2}}}
+
+	v.pruneJobs()
+	assert.NotNil(t, v.jobs.get(1), "Verify the job whose output is shown is kept")
+	assert.NotNil(t, v.jobs.get(2), "Verify jobs a bulk run is waiting on are kept")
+	assert.Nil(t, v.jobs.get(3))
+	assert.Len(t, v.jobs.jobs, maxFinishedJobs+2)
+}
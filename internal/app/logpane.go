@@ -0,0 +1,75 @@
+package app
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const logPaneHeight = 10
+
+// logPane shows the live output of the latest job for the selected machine or environment.
+type logPane struct {
+	viewport viewport.Model
+	// The target currently being shown, and how much of its output
+	target string
+	lines  int
+	// Set when the content needs wrapping again
+	stale bool
+}
+
+func newLogPane() logPane {
+	vp := viewport.New(0, logPaneHeight)
+	// Up/down are taken by machine selection, so only page through the log.
+	vp.KeyMap = viewport.KeyMap{
+		PageDown: keys.ScrollDown,
+		PageUp:   keys.ScrollUp,
+	}
+	return logPane{viewport: vp}
+}
+
+func (lp *logPane) setWidth(width int) {
+	lp.viewport.Width = width - logPaneStyle.GetHorizontalFrameSize()
+	lp.stale = true
+}
+
+// Show output for target, following new lines unless the user has scrolled away.
+func (lp *logPane) show(target string, output []string) {
+	if target == lp.target && len(output) == lp.lines && !lp.stale {
+		return
+	}
+	lp.stale = false
+	follow := target != lp.target || lp.viewport.AtBottom()
+	lp.target = target
+	lp.lines = len(output)
+
+	content := strings.Join(output, "\n")
+	if lp.viewport.Width > 0 {
+		content = lipgloss.NewStyle().Width(lp.viewport.Width).Render(content)
+	}
+	lp.viewport.SetContent(content)
+	if follow {
+		lp.viewport.GotoBottom()
+	}
+}
+
+func (lp *logPane) Update(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	lp.viewport, cmd = lp.viewport.Update(msg)
+	return cmd
+}
+
+// Whether there's any output to show for the current target
+func (lp *logPane) hasOutput() bool {
+	return lp.lines > 0
+}
+
+func (lp *logPane) View(title string) string {
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		logPaneTitleStyle.Render("Output of "+title),
+		logPaneStyle.Render(lp.viewport.View()),
+	)
+}
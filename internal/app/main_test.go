@@ -0,0 +1,20 @@
+package app
+
+import (
+	"os"
+	"testing"
+
+	"github.com/braheezy/violet/pkg/vagrant/vagranttest"
+	"github.com/charmbracelet/lipgloss"
+	zone "github.com/lrstanley/bubblezone"
+	"github.com/muesli/termenv"
+)
+
+func TestMain(m *testing.M) {
+	// The tests run Vagrant as a fake, which is this test binary
+	vagranttest.Main()
+	// Golden files are plain text, whatever terminal the tests run in
+	lipgloss.SetColorProfile(termenv.Ascii)
+	zone.NewGlobal()
+	os.Exit(m.Run())
+}
@@ -0,0 +1,149 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// A marked machine and the environment it's in.
+type markedMachine struct {
+	env     *Environment
+	machine *Machine
+}
+
+// Whether machine is marked for a bulk run.
+func (e *Ecosystem) isMarked(machine *Machine) bool {
+	return e.marked[machine.logTarget()]
+}
+
+// Mark the selected machine, or unmark it if it already is. When the environment has
+// focus, every machine in it is marked, unless they all are already.
+func (e *Ecosystem) toggleMark() {
+	if !e.hasSelectedEnv() {
+		return
+	}
+	if e.marked == nil {
+		e.marked = make(map[string]bool)
+	}
+	env := e.currentEnv()
+	if env.hasFocus {
+		all := true
+		for i := range env.machines {
+			all = all && e.isMarked(&env.machines[i])
+		}
+		for _, machine := range env.machines {
+			e.setMark(machine, !all)
+		}
+		return
+	}
+	if machine, err := e.currentMachine(); err == nil {
+		e.setMark(*machine, !e.isMarked(machine))
+	}
+}
+
+func (e *Ecosystem) setMark(machine Machine, marked bool) {
+	if marked {
+		e.marked[machine.logTarget()] = true
+	} else {
+		delete(e.marked, machine.logTarget())
+	}
+}
+
+// Every marked machine that still exists, whether the filter shows it or not, in the order the tabs show them.
+func (e *Ecosystem) markedMachines() []markedMachine {
+	var marked []markedMachine
+	environments := e.everything()
+	for i := range environments {
+		env := &environments[i]
+		for j := range env.machines {
+			if e.isMarked(&env.machines[j]) {
+				marked = append(marked, markedMachine{env: env, machine: &env.machines[j]})
+			}
+		}
+	}
+	return marked
+}
+
+// bulkRun is a command run on several machines at once, summed up once every one has finished.
+type bulkRun struct {
+	command string
+	// The jobs running the command, one per machine
+	jobs []int
+}
+
+// Queue a job running command on every marked machine, asking first because
+// they're easy to forget about when they're in other environments.
+func (v *Violet) confirmBulkRun(command string, marked []markedMachine) {
+	homes := make(map[string]bool)
+	for _, m := range marked {
+		homes[m.env.home] = true
+	}
+	run := func(v *Violet) tea.Cmd {
+		bulk := &bulkRun{command: command}
+		var cmds []tea.Cmd
+		for _, m := range marked {
+			cmds = append(cmds, v.createMachineRunCmd(command, m.env, m.machine))
+			bulk.jobs = append(bulk.jobs, v.jobs.nextID)
+		}
+		v.bulkRuns = append(v.bulkRuns, bulk)
+		return tea.Batch(cmds...)
+	}
+
+	prompt := fmt.Sprintf("Really %v %v machines in %v environments?", command, len(marked), len(homes))
+	if len(homes) == 1 {
+		prompt = fmt.Sprintf("Really %v %v machines in %v?", command, len(marked), marked[0].env.name)
+	}
+	requiredInput := ""
+	if destructiveCommands[command] {
+		// Affects a lot of machines, so make them type how many
+		requiredInput = fmt.Sprint(len(marked))
+	}
+	v.confirm = newConfirmDialog(prompt, requiredInput, run)
+}
+
+// Sum up every bulk run whose jobs have all finished.
+func (v *Violet) finishBulkRuns() {
+	v.bulkRuns = slices.DeleteFunc(v.bulkRuns, func(bulk *bulkRun) bool {
+		var succeeded, cancelled int
+		var failed []string
+		for _, id := range bulk.jobs {
+			j := v.jobs.get(id)
+			switch {
+			case j == nil:
+				continue
+			case j.status == jobQueued || j.status == jobRunning:
+				return false
+			case j.status == jobSucceeded:
+				succeeded++
+			case j.status == jobFailed:
+				failed = append(failed, j.target.label)
+			default:
+				cancelled++
+			}
+		}
+		summary := fmt.Sprintf("%v on %v machines: %v succeeded", bulk.command, len(bulk.jobs), succeeded)
+		if len(failed) > 0 {
+			summary += fmt.Sprintf(", %v failed (%v)", len(failed), strings.Join(failed, ", "))
+		}
+		if cancelled > 0 {
+			summary += fmt.Sprintf(", %v cancelled", cancelled)
+		}
+		v.notice = summary
+		return true
+	})
+}
+
+// How many machines are marked, if any are.
+func (v *Violet) marksView() string {
+	marked := len(v.ecosystem.markedMachines())
+	if marked == 0 {
+		return ""
+	}
+	return strings.Join([]string{
+		markStyle.Render(fmt.Sprintf("● %v marked", marked)),
+		panelHintStyle.Render(helpLine(v.keys.Execute) + " on all • " + helpLine(v.keys.ClearMarks)),
+	}, "  ")
+}
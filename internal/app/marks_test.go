@@ -0,0 +1,109 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/braheezy/violet/pkg/vagrant"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	markKey       = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")}
+	clearMarksKey = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("X")}
+	yes           = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")}
+)
+
+// The labels of the marked machines.
+func markedLabels(v Violet) []string {
+	var labels []string
+	for _, m := range v.ecosystem.markedMachines() {
+		labels = append(labels, machineTarget(m.env, m.machine).label)
+	}
+	return labels
+}
+
+func TestMarks(t *testing.T) {
+	eco, err := createEcosystem(newTestEcosystem(3))
+	require.NoError(t, err)
+	v := press(newViolet(vagrant.NewSimulator()), ecosystemMsg(eco))
+
+	// app01/web, then app02/db
+	v = press(v, space, markKey, tab, space, down, markKey)
+	assert.Equal(t, []string{"app01/web", "app02/db"}, markedLabels(v))
+
+	v = press(v, tab, markKey)
+	assert.Equal(t, []string{"app01/web", "app02/db", "app03/web", "app03/db"}, markedLabels(v), "Verify the environment marks all its machines")
+	requireGoldenView(t, v)
+	v = press(v, markKey)
+	assert.Equal(t, []string{"app01/web", "app02/db"}, markedLabels(v), "Verify the environment unmarks them again")
+
+	v.ecosystem.setFilter("app03")
+	assert.Equal(t, []string{"app01/web", "app02/db"}, markedLabels(v), "Verify hidden machines stay marked")
+	v.ecosystem.setFilter("")
+
+	v = press(v, clearMarksKey)
+	assert.Empty(t, markedLabels(v))
+	assert.Empty(t, v.marksView())
+}
+
+func TestBulkRun(t *testing.T) {
+	eco, err := createEcosystem(newTestEcosystem(3))
+	require.NoError(t, err)
+	v := press(newViolet(vagrant.NewSimulator()), ecosystemMsg(eco))
+	v.jobs = newJobManager(2)
+	v = press(v, space, markKey, down, markKey, tab, space, markKey, tab, space, markKey)
+
+	// Halt them all from the card of the last one
+	v = press(v, right, enter)
+	require.NotNil(t, v.confirm)
+	assert.Equal(t, "Really halt 4 machines in 3 environments?", v.confirm.prompt)
+	v = press(v, yes)
+	require.Len(t, v.jobs.jobs, 4)
+	assert.Equal(t, 2, v.jobs.running(), "Verify only as many as parallelism allows run at once")
+	for _, j := range v.jobs.jobs {
+		assert.Equal(t, "halt", j.command)
+	}
+
+	v = press(v, jobDoneMsg{id: 1}, jobDoneMsg{id: 2, err: errors.New("exit status 1")}, jobDoneMsg{id: 3})
+	assert.Empty(t, v.notice, "Verify there's no summary until every job is done")
+	v = press(v, jobDoneMsg{id: 4})
+	assert.Equal(t, "halt on 4 machines: 3 succeeded, 1 failed (app01/db)", v.notice)
+	assert.Empty(t, v.bulkRuns)
+	assert.Len(t, markedLabels(v), 4, "Verify the machines stay marked for the next command")
+}
+
+func TestBulkRunDestructive(t *testing.T) {
+	eco, err := createEcosystem(newTestEcosystem(2))
+	require.NoError(t, err)
+	v := press(newViolet(vagrant.NewSimulator()), ecosystemMsg(eco))
+	v = press(v, markKey, space)
+	// The last command is destroy
+	v = press(v, tea.KeyMsg{Type: tea.KeyLeft}, enter)
+	require.NotNil(t, v.confirm)
+	assert.Equal(t, "Really destroy 2 machines in app01?", v.confirm.prompt)
+
+	v = press(v, yes, enter)
+	assert.NotNil(t, v.confirm, "Verify the number of machines has to be typed")
+	assert.Empty(t, v.jobs.jobs)
+	v = press(v, tea.KeyMsg{Type: tea.KeyBackspace}, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("2")}, enter)
+	assert.Nil(t, v.confirm)
+	assert.Len(t, v.jobs.jobs, 2)
+}
+
+func TestBulkRunCancelled(t *testing.T) {
+	eco, err := createEcosystem(newTestEcosystem(1))
+	require.NoError(t, err)
+	v := press(newViolet(vagrant.NewSimulator()), ecosystemMsg(eco))
+	v.jobs = newJobManager(1)
+	v = press(v, markKey, space, enter, yes)
+	require.Len(t, v.jobs.jobs, 2)
+
+	// Cancel the queued one, on the db card
+	v = press(v, down, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+	assert.Empty(t, v.notice)
+	v = press(v, jobDoneMsg{id: 1})
+	assert.Equal(t, "up on 2 machines: 1 succeeded, 1 cancelled", v.notice)
+}
@@ -0,0 +1,111 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/braheezy/violet/pkg/vagrant"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// How often the machine index is checked for changes made by Vagrant.
+const machineIndexPollInterval = 2 * time.Second
+
+// refreshTickMsg is emitted when it's time for the periodic refresh.
+type refreshTickMsg struct{}
+
+// machineIndexMsg is emitted when Vagrant has changed its machine index.
+type machineIndexMsg struct{}
+
+// ecosystemRefreshMsg is emitted when every machine has been looked up again.
+type ecosystemRefreshMsg struct {
+	ecosystem Ecosystem
+	// Whether each environment should be asked for its status afterwards
+	checkStatus bool
+	err         error
+}
+
+// Create the tea.Cmd that waits for the next periodic refresh. Nothing is
+// scheduled if refreshing is turned off.
+func (v *Violet) scheduleRefresh() tea.Cmd {
+	if v.refreshInterval <= 0 {
+		return nil
+	}
+	return tea.Tick(v.refreshInterval, func(time.Time) tea.Msg { return refreshTickMsg{} })
+}
+
+// Runners that can tell when Vagrant changes its machine index, like VagrantClient.
+type machineIndexWatcher interface {
+	WatchMachineIndex(ctx context.Context, interval time.Duration) <-chan struct{}
+}
+
+// Start watching the machine index, if runner can. Changes are only picked up
+// by the periodic refresh otherwise.
+func watchMachineIndex(runner vagrant.Runner) <-chan struct{} {
+	if watcher, ok := runner.(machineIndexWatcher); ok {
+		return watcher.WatchMachineIndex(context.Background(), machineIndexPollInterval)
+	}
+	return nil
+}
+
+// Create the tea.Cmd that waits for the next change to the machine index.
+func waitForMachineIndex(changes <-chan struct{}) tea.Cmd {
+	if changes == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		if _, ok := <-changes; ok {
+			return machineIndexMsg{}
+		}
+		return nil
+	}
+}
+
+// Create the tea.Cmd that looks up every machine again, unless that's already happening.
+// With checkStatus, each environment is then asked for its real state.
+func (v *Violet) startRefresh(checkStatus bool) tea.Cmd {
+	if v.refreshing {
+		return nil
+	}
+	v.refreshing = true
+	client := v.ecosystem.client
+	return func() tea.Msg {
+		ecosystem, err := createEcosystem(client)
+		return ecosystemRefreshMsg{ecosystem: ecosystem, checkStatus: checkStatus, err: err}
+	}
+}
+
+// Swap in the refreshed ecosystem. The machine index is only as fresh as the
+// last Vagrant command, so environments may be asked for their real state too.
+//
+// Asking Vagrant for status writes to the index, which is why that's not done
+// when the refresh was because the index changed.
+func (v *Violet) finishRefresh(msg ecosystemRefreshMsg) tea.Cmd {
+	v.refreshing = false
+	if msg.err != nil {
+		v.setErrorMessage(msg.err.Error())
+		return nil
+	}
+	v.ecosystem.refresh(msg.ecosystem)
+	v.lastRefreshed = time.Now()
+	if !msg.checkStatus {
+		return nil
+	}
+
+	var cmds []tea.Cmd
+	for _, env := range v.ecosystem.everything() {
+		cmds = append(cmds, v.createEnvStatusCmd(env.home))
+	}
+	return tea.Batch(cmds...)
+}
+
+// When the machines were last refreshed, for the view
+func (v *Violet) refreshView() string {
+	if v.refreshing {
+		return refreshStyle.Render("Refreshing...")
+	}
+	if v.lastRefreshed.IsZero() {
+		return ""
+	}
+	return refreshStyle.Render("Last refreshed at " + v.lastRefreshed.Format(time.TimeOnly))
+}
@@ -0,0 +1,213 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/braheezy/violet/internal/config"
+	"github.com/braheezy/violet/pkg/vagrant"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Keys used inside the snapshot panel.
+type snapshotKeyMap struct {
+	Up      key.Binding
+	Down    key.Binding
+	Create  key.Binding
+	Restore key.Binding
+	Delete  key.Binding
+	Close   key.Binding
+}
+
+var snapshotKeys = newSnapshotKeyMap(config.DefaultKeys())
+
+func newSnapshotKeyMap(k config.Keys) snapshotKeyMap {
+	closing := newBinding(k, "close", "snapshot_close")
+	// The key that opened the panel closes it too
+	closing.SetKeys(append(closing.Keys(), k["snapshots"]...)...)
+	return snapshotKeyMap{
+		Up:      newBinding(k, "", "up"),
+		Down:    newBinding(k, "", "down"),
+		Create:  newBinding(k, "new", "snapshot_new"),
+		Restore: newBinding(k, "restore", "snapshot_restore"),
+		Delete:  newBinding(k, "delete", "snapshot_delete"),
+		Close:   closing,
+	}
+}
+
+// snapshotPanel lists the snapshots of a machine and manages them.
+type snapshotPanel struct {
+	target jobTarget
+	// nil until the list has been fetched
+	snapshots []vagrant.Snapshot
+	loading   bool
+	err       string
+	selected  int
+	// Prompt for the name of a new snapshot, when one is being taken
+	naming    bool
+	nameInput textinput.Model
+}
+
+func newSnapshotPanel(target jobTarget) *snapshotPanel {
+	input := textinput.New()
+	input.Placeholder = "snapshot name"
+	// Names end up on the Vagrant command line
+	input.Validate = func(name string) error {
+		if strings.ContainsAny(name, " \t,") {
+			return errors.New("snapshot names can't contain spaces or commas")
+		}
+		return nil
+	}
+	return &snapshotPanel{
+		target:    target,
+		loading:   true,
+		nameInput: input,
+	}
+}
+
+// snapshotListMsg is emitted when the snapshots of a machine have been listed.
+type snapshotListMsg struct {
+	// key identifies the machine
+	key       string
+	snapshots []vagrant.Snapshot
+	err       error
+}
+
+// Create the tea.Cmd that lists the snapshots of the machine the panel is for.
+func (v *Violet) createSnapshotListCmd(target jobTarget) tea.Cmd {
+	client := v.ecosystem.client
+	return func() tea.Msg {
+		log.Printf("Listing snapshots of %v", target.machineID)
+		snapshots, err := vagrant.ListSnapshots(context.Background(), client, target.machineID)
+		return snapshotListMsg{key: target.key, snapshots: snapshots, err: err}
+	}
+}
+
+// Queue the job that runs `vagrant snapshot <subcommand>` on the panel's machine.
+func (v *Violet) createSnapshotRunCmd(target jobTarget, subcommand string, name string) tea.Cmd {
+	client := v.ecosystem.client
+	return v.jobs.submit(target, "snapshot "+subcommand+" "+name, func(ctx context.Context) *vagrant.Stream {
+		log.Printf("Running snapshot %v %v on %v", subcommand, name, target.machineID)
+		return client.Stream(ctx, "", vagrant.SnapshotArgs(subcommand, target.machineID, name)...)
+	})
+}
+
+// Open the snapshot panel for the selected machine.
+func (v *Violet) openSnapshotPanel() tea.Cmd {
+	machine, err := v.ecosystem.currentMachine()
+	if err != nil {
+		v.setErrorMessage(err.Error())
+		return nil
+	}
+	if machine.machineID == "" {
+		v.setErrorMessage(fmt.Sprintf("%v has no ID yet, bring it up before taking snapshots", machine.name))
+		return nil
+	}
+	v.snapshots = newSnapshotPanel(machineTarget(v.ecosystem.currentEnv(), machine))
+	return v.createSnapshotListCmd(v.snapshots.target)
+}
+
+// Handle a key press while the snapshot panel is open.
+func (v *Violet) updateSnapshotPanel(msg tea.KeyMsg) tea.Cmd {
+	panel := v.snapshots
+	if panel.naming {
+		switch msg.Type {
+		case tea.KeyEsc:
+			panel.naming = false
+			return nil
+		case tea.KeyEnter:
+			name := panel.nameInput.Value()
+			if name == "" || panel.nameInput.Err != nil {
+				return nil
+			}
+			panel.naming = false
+			return v.createSnapshotRunCmd(panel.target, "save", name)
+		}
+		var cmd tea.Cmd
+		panel.nameInput, cmd = panel.nameInput.Update(msg)
+		return cmd
+	}
+
+	switch {
+	case key.Matches(msg, snapshotKeys.Close):
+		v.snapshots = nil
+	case key.Matches(msg, snapshotKeys.Up):
+		if panel.selected > 0 {
+			panel.selected--
+		}
+	case key.Matches(msg, snapshotKeys.Down):
+		if panel.selected < len(panel.snapshots)-1 {
+			panel.selected++
+		}
+	case key.Matches(msg, snapshotKeys.Create):
+		panel.naming = true
+		panel.nameInput.Reset()
+		panel.nameInput.Focus()
+		return textinput.Blink
+	case key.Matches(msg, snapshotKeys.Restore), key.Matches(msg, snapshotKeys.Delete):
+		if panel.selected >= len(panel.snapshots) {
+			return nil
+		}
+		subcommand := "restore"
+		if key.Matches(msg, snapshotKeys.Delete) {
+			subcommand = "delete"
+		}
+		name := panel.snapshots[panel.selected].Name
+		target := panel.target
+		v.confirm = newConfirmDialog(
+			fmt.Sprintf("Really %v snapshot %v of %v?", subcommand, name, target.label),
+			"",
+			func(v *Violet) tea.Cmd { return v.createSnapshotRunCmd(target, subcommand, name) },
+		)
+	}
+	return nil
+}
+
+func (p *snapshotPanel) View() string {
+	title := panelTitleStyle.Render("Snapshots of " + p.target.label)
+
+	var body string
+	switch {
+	case p.loading:
+		body = panelHintStyle.Render("Looking for snapshots...")
+	case p.err != "":
+		body = errorStyle.UnsetMargins().Render(p.err)
+	case len(p.snapshots) == 0:
+		body = panelHintStyle.Render("No snapshots yet")
+	default:
+		var rows []string
+		for i, snapshot := range p.snapshots {
+			if i == p.selected {
+				rows = append(rows, panelSelectedItemStyle.Render(snapshot.Name))
+			} else {
+				rows = append(rows, panelItemStyle.Render(snapshot.Name))
+			}
+		}
+		body = strings.Join(rows, "\n")
+	}
+
+	footer := panelHintStyle.Render(helpLine(snapshotKeys.Create, snapshotKeys.Restore, snapshotKeys.Delete, snapshotKeys.Close))
+	if p.naming {
+		footer = lipgloss.JoinVertical(lipgloss.Left, p.nameInput.View(), panelHintStyle.Render("⏎ take snapshot • esc cancel"))
+		if p.nameInput.Err != nil {
+			footer = lipgloss.JoinVertical(lipgloss.Left, footer, errorStyle.UnsetMargins().Render(p.nameInput.Err.Error()))
+		}
+	}
+
+	return panelStyle.Render(lipgloss.JoinVertical(lipgloss.Left, title, "", body, "", footer))
+}
+
+// Render the help of bindings on a single line
+func helpLine(bindings ...key.Binding) string {
+	var parts []string
+	for _, binding := range bindings {
+		parts = append(parts, binding.Help().Key+" "+binding.Help().Desc)
+	}
+	return strings.Join(parts, " • ")
+}
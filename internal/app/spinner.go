@@ -4,7 +4,6 @@ import (
 	"math/rand"
 
 	"github.com/charmbracelet/bubbles/spinner"
-	"github.com/charmbracelet/lipgloss"
 )
 
 var (
@@ -20,25 +19,18 @@ var (
 		spinner.Moon,
 		spinner.Monkey,
 	}
-
-	spinnerStyle = lipgloss.NewStyle().
-			Foreground(secondaryColor).
-			Italic(true)
-	spinnerCommandStyle = spinnerStyle.
-				Bold(true).
-				Foreground(secondaryColor)
 )
 
-type currentSpinner struct {
+// Each job gets its own spinner, with a random look and verb.
+type jobSpinner struct {
 	spinner spinner.Model
-	show    bool
 	verb    string
 }
 
-func newSpinner() currentSpinner {
+func newJobSpinner() jobSpinner {
 	s := spinner.New()
 	s.Spinner = spinners[rand.Intn(len(spinners))]
-	return currentSpinner{
+	return jobSpinner{
 		spinner: s,
 		verb:    verbs[rand.Intn(len(verbs))],
 	}
@@ -1,6 +1,8 @@
 package app
 
 import (
+	"strings"
+
 	"github.com/charmbracelet/lipgloss"
 	tint "github.com/lrstanley/bubbletint"
 )
@@ -8,13 +10,12 @@ import (
 // Tweak these to quickly change app feels
 var defaultDarkTheme = tint.TintKonsolas
 var defaultLightTheme = tint.TintCatppuccinLatte
-var theme = defaultDarkTheme
+var theme tint.Tint
 
-// Tweak these for a different palette
 var (
-	primaryColor   = theme.Purple()
-	secondaryColor = theme.Cyan()
-	textColor      = theme.Fg()
+	marginVertical   = 1
+	marginHorizontal = 2
+	textWrap         = 12
 
 	inactiveTabBorder = tabBorderWithBottom("┴", "─", "┴")
 	activeTabBorder   = tabBorderWithBottom("┘", " ", "└")
@@ -28,42 +29,6 @@ var (
 		BottomLeft:  "─",
 		BottomRight: "╮",
 	}
-
-	marginVertical   = 1
-	marginHorizontal = 2
-	textWrap         = 12
-
-	titleStyle = lipgloss.NewStyle().
-			Bold(true).
-			Italic(true).
-			Foreground(primaryColor).
-			Padding(0, 1)
-	greeterStyle = lipgloss.NewStyle().
-			Foreground(secondaryColor)
-
-	highlightColor   = primaryColor
-	inactiveTabStyle = lipgloss.NewStyle().
-				Border(inactiveTabBorder, true).
-				BorderForeground(highlightColor).
-				Padding(0, 1).
-				Foreground(textColor)
-	activeTabStyle = inactiveTabStyle.
-			Border(activeTabBorder, true).
-			Foreground(secondaryColor)
-	tabGapStyle = inactiveTabStyle.
-			Border(gapBorder)
-	tabWindowStyle = lipgloss.NewStyle().
-			BorderForeground(highlightColor).
-			Padding(1, 1).
-			Border(lipgloss.RoundedBorder()).
-			UnsetBorderTop()
-	errorTitleStyle = lipgloss.NewStyle().
-			Foreground(theme.Red()).
-			MarginLeft(marginHorizontal)
-	errorStyle = lipgloss.NewStyle().
-			MarginLeft(marginHorizontal).
-			Foreground(theme.BrightRed()).
-			Bold(true)
 )
 
 var verbs = []string{"Running", "Executing", "Performing", "Invoking", "Launching", "Casting"}
@@ -76,36 +41,292 @@ func tabBorderWithBottom(left, middle, right string) lipgloss.Border {
 	return border
 }
 
-// ------ Card Style ---------
+// Every color and style, see setTheme.
 var (
+	primaryColor   lipgloss.TerminalColor
+	secondaryColor lipgloss.TerminalColor
+	textColor      lipgloss.TerminalColor
+	highlightColor lipgloss.TerminalColor
+	statusColors   map[string]lipgloss.TerminalColor
+
+	titleStyle       lipgloss.Style
+	greeterStyle     lipgloss.Style
+	inactiveTabStyle lipgloss.Style
+	activeTabStyle   lipgloss.Style
+	tabGapStyle      lipgloss.Style
+	tabWindowStyle   lipgloss.Style
+	errorTitleStyle  lipgloss.Style
+	errorStyle       lipgloss.Style
+
+	cardTitleStyle       lipgloss.Style
+	cardStatusStyle      lipgloss.Style
+	cardProviderStyle    lipgloss.Style
+	defaultCardStyle     lipgloss.Style
+	selectedCardStyle    lipgloss.Style
+	envCardTitleStyle    lipgloss.Style
+	selectedEnvCardStyle lipgloss.Style
+	envHomeStyle         lipgloss.Style
+	markStyle            lipgloss.Style
+	tooltipStyle         lipgloss.Style
+	refreshStyle         lipgloss.Style
+
+	defaultLargeButtonStyle lipgloss.Style
+	activeLargeButtonStyle  lipgloss.Style
+	buttonLargeGroupStyle   lipgloss.Style
+	defaultSmallButtonStyle lipgloss.Style
+	activeSmallButtonStyle  lipgloss.Style
+	buttonSmallGroupStyle   lipgloss.Style
+
+	confirmDialogStyle lipgloss.Style
+	confirmPromptStyle lipgloss.Style
+	confirmHintStyle   lipgloss.Style
+
+	panelStyle             lipgloss.Style
+	panelTitleStyle        lipgloss.Style
+	panelItemStyle         lipgloss.Style
+	panelSelectedItemStyle lipgloss.Style
+	panelHintStyle         lipgloss.Style
+
+	boxNameStyle     lipgloss.Style
+	boxOutdatedStyle lipgloss.Style
+
+	jobsPanelStyle  lipgloss.Style
+	jobCommandStyle lipgloss.Style
+	jobFaintStyle   lipgloss.Style
+	spinnerStyle    lipgloss.Style
+
+	logPaneStyle      lipgloss.Style
+	logPaneTitleStyle lipgloss.Style
+)
+
+// What each state Vagrant's providers report means, so states that mean the same thing look
+// the same whichever provider named them. Underscores are spaces, as the cards show them.
+var stateKinds = map[string]string{
+	"running":            "running",
+	"starting":           "changing",
+	"stopping":           "changing",
+	"shutting down":      "changing",
+	"saving":             "changing",
+	"restoring":          "changing",
+	"preparing":          "changing",
+	"saved":              "suspended",
+	"suspended":          "suspended",
+	"paused":             "suspended",
+	"frozen":             "suspended",
+	"poweroff":           "stopped",
+	"shutoff":            "stopped",
+	"shutdown":           "stopped",
+	"stopped":            "stopped",
+	"off":                "stopped",
+	"not running":        "stopped",
+	"aborted":            "broken",
+	"gurumeditation":     "broken",
+	"inaccessible":       "broken",
+	"error":              "broken",
+	"unknown":            "broken",
+	"host state unknown": "broken",
+	"not created":        "not created",
+}
+
+// The color of a machine state. States nobody knows are plain text.
+func statusColor(state string) lipgloss.TerminalColor {
+	if color, ok := statusColors[strings.ReplaceAll(state, "_", " ")]; ok {
+		return color
+	}
+	return textColor
+}
+
+func init() {
+	setTheme(defaultDarkTheme)
+}
+
+// The built in theme called id, if there is one.
+func themeByID(id string) (tint.Tint, bool) {
+	for _, t := range tint.DefaultTints() {
+		if t.ID() == id {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// Color everything with t.
+func setTheme(t tint.Tint) {
+	theme = t
+
+	// Tweak these for a different palette
+	primaryColor = theme.Purple()
+	secondaryColor = theme.Cyan()
+	textColor = theme.Fg()
+	highlightColor = primaryColor
+
+	titleStyle = lipgloss.NewStyle().
+		Bold(true).
+		Italic(true).
+		Foreground(primaryColor).
+		Padding(0, 1)
+	greeterStyle = lipgloss.NewStyle().
+		Foreground(secondaryColor)
+
+	inactiveTabStyle = lipgloss.NewStyle().
+		Border(inactiveTabBorder, true).
+		BorderForeground(highlightColor).
+		Padding(0, 1).
+		Foreground(textColor)
+	activeTabStyle = inactiveTabStyle.
+		Border(activeTabBorder, true).
+		Foreground(secondaryColor)
+	tabGapStyle = inactiveTabStyle.
+		Border(gapBorder)
+	tabWindowStyle = lipgloss.NewStyle().
+		BorderForeground(highlightColor).
+		Padding(1, 1).
+		Border(lipgloss.RoundedBorder()).
+		UnsetBorderTop()
+	errorTitleStyle = lipgloss.NewStyle().
+		Foreground(theme.Red()).
+		MarginLeft(marginHorizontal)
+	errorStyle = lipgloss.NewStyle().
+		MarginLeft(marginHorizontal).
+		Foreground(theme.BrightRed()).
+		Bold(true)
+
+	// ------ Card Style ---------
 	cardTitleStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(primaryColor).
-			Width(textWrap)
+		Bold(true).
+		Foreground(primaryColor).
+		Width(textWrap)
 	cardStatusStyle = lipgloss.NewStyle()
-	statusColors    = map[string]lipgloss.TerminalColor{
+	kindColors := map[string]lipgloss.TerminalColor{
 		"running":     theme.Green(),
-		"shutoff":     theme.Red(),
+		"changing":    theme.Yellow(),
+		"suspended":   theme.Blue(),
 		"stopped":     theme.Red(),
-		"not started": theme.Black(),
+		"broken":      theme.BrightRed(),
+		"not created": theme.BrightBlack(),
+	}
+	statusColors = make(map[string]lipgloss.TerminalColor, len(stateKinds))
+	for state, kind := range stateKinds {
+		statusColors[state] = kindColors[kind]
 	}
 	cardProviderStyle = lipgloss.NewStyle().
-				Faint(true).
-				Italic(true).
-				Foreground(textColor)
+		Faint(true).
+		Italic(true).
+		Foreground(textColor)
 	defaultCardStyle = lipgloss.NewStyle().
-				Border(lipgloss.RoundedBorder(), false, false, false, false).
-				PaddingLeft(2)
+		Border(lipgloss.RoundedBorder(), false, false, false, false).
+		PaddingLeft(2)
 	selectedCardStyle = defaultCardStyle.
-				BorderLeft(true).
-				Border(lipgloss.RoundedBorder(), false, false, false, true).
-				BorderForeground(secondaryColor).
-				PaddingLeft(1)
+		BorderLeft(true).
+		Border(lipgloss.RoundedBorder(), false, false, false, true).
+		BorderForeground(secondaryColor).
+		PaddingLeft(1)
 
 	envCardTitleStyle = cardTitleStyle.
-				MarginLeft(1).
-				Width(textWrap)
+		MarginLeft(1).
+		Width(textWrap)
 	selectedEnvCardStyle = envCardTitleStyle.
-				Bold(true).
-				Foreground(secondaryColor)
-)
+		Bold(true).
+		Foreground(secondaryColor)
+	envHomeStyle = lipgloss.NewStyle().
+		MarginLeft(1).
+		Faint(true).
+		Italic(true).
+		Foreground(textColor)
+	markStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(secondaryColor)
+	tooltipStyle = lipgloss.NewStyle().
+		Faint(true).
+		Foreground(textColor)
+	refreshStyle = lipgloss.NewStyle().
+		Faint(true).
+		Italic(true).
+		Foreground(textColor)
+
+	// ------ Buttons ---------
+	defaultLargeButtonStyle = lipgloss.NewStyle().
+		Foreground(primaryColor).
+		Padding(1)
+	activeLargeButtonStyle = defaultLargeButtonStyle.
+		Foreground(secondaryColor).
+		Bold(true)
+	buttonLargeGroupStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder(), true).
+		BorderForeground(primaryColor).
+		Margin(0)
+
+	defaultSmallButtonStyle = lipgloss.NewStyle().
+		Foreground(primaryColor).
+		Margin(0, 1)
+	activeSmallButtonStyle = defaultSmallButtonStyle.
+		Foreground(secondaryColor).
+		Bold(true)
+	buttonSmallGroupStyle = lipgloss.NewStyle().
+		Margin(marginVertical, marginHorizontal, 0).
+		Border(lipgloss.RoundedBorder(), true).
+		BorderForeground(primaryColor)
+
+	// ------ Dialogs and panels ---------
+	confirmDialogStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(theme.Red()).
+		Padding(1, 2)
+	confirmPromptStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(textColor)
+	confirmHintStyle = lipgloss.NewStyle().
+		Faint(true).
+		Italic(true).
+		Foreground(textColor)
+
+	panelStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(primaryColor).
+		Padding(1, 2)
+	panelTitleStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(primaryColor)
+	panelItemStyle = lipgloss.NewStyle().
+		Foreground(textColor).
+		PaddingLeft(2)
+	panelSelectedItemStyle = lipgloss.NewStyle().
+		Foreground(secondaryColor).
+		Bold(true).
+		Border(lipgloss.NormalBorder(), false, false, false, true).
+		BorderForeground(secondaryColor).
+		PaddingLeft(1)
+	panelHintStyle = lipgloss.NewStyle().
+		Faint(true).
+		Italic(true).
+		Foreground(textColor)
+
+	boxNameStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(primaryColor)
+	boxOutdatedStyle = lipgloss.NewStyle().
+		Foreground(theme.Yellow())
+
+	// ------ Jobs and their output ---------
+	jobsPanelStyle = lipgloss.NewStyle().
+		Margin(0, marginHorizontal)
+	jobCommandStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(secondaryColor)
+	jobFaintStyle = lipgloss.NewStyle().
+		Faint(true).
+		Foreground(textColor)
+	spinnerStyle = lipgloss.NewStyle().
+		Foreground(secondaryColor).
+		Italic(true)
+
+	logPaneStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(primaryColor).
+		Foreground(textColor).
+		Margin(0, marginHorizontal)
+	logPaneTitleStyle = lipgloss.NewStyle().
+		Foreground(secondaryColor).
+		Italic(true).
+		MarginLeft(marginHorizontal)
+}
@@ -0,0 +1,219 @@
+package app
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	// How many machines the table shows at once
+	tableRows = 15
+	// Longer cells are cut short
+	tableCellWidth = 40
+)
+
+// The table's columns, in order
+var tableColumns = []string{"ENVIRONMENT", "MACHINE", "PROVIDER", "STATE", "ID", "HOME"}
+
+// Index of the state column, which is colored
+const tableStateColumn = 3
+
+// machineTable shows every machine in every environment at once, instead of one environment per tab.
+// The selected row is the ecosystem's selected machine, so everything that works on cards works on rows too.
+type machineTable struct {
+	// Index of the column the rows are sorted by
+	sortColumn int
+	descending bool
+}
+
+// A machine in the table.
+type tableRow struct {
+	// Where the machine is in the ecosystem
+	env, machine int
+	cells        []string
+}
+
+// Every machine the ecosystem shows, sorted.
+func (t *machineTable) rows(e *Ecosystem) []tableRow {
+	var rows []tableRow
+	for i, env := range e.environments {
+		for j, machine := range env.machines {
+			rows = append(rows, tableRow{
+				env:     i,
+				machine: j,
+				cells:   []string{env.name, machine.name, machine.provider, machine.state, machine.machineID, env.home},
+			})
+		}
+	}
+	// Ties stay in the order the cards show them
+	slices.SortStableFunc(rows, func(a, b tableRow) int {
+		order := cmp.Compare(a.cells[t.sortColumn], b.cells[t.sortColumn])
+		if t.descending {
+			return -order
+		}
+		return order
+	})
+	return rows
+}
+
+// Index of the selected machine's row, or -1 if none is selected.
+func selectedRow(e *Ecosystem, rows []tableRow) int {
+	if !e.hasSelectedEnv() {
+		return -1
+	}
+	return slices.IndexFunc(rows, func(row tableRow) bool {
+		return row.env == e.selectedEnv && row.machine == e.selectedMachine
+	})
+}
+
+// Select the machine in row, and the tab it's in for when the cards are back.
+func (e *Ecosystem) selectRow(row tableRow) {
+	e.selectedEnv = row.env
+	e.selectedMachine = row.machine
+	e.currentEnv().hasFocus = false
+	e.envPager.moreIsSelected = false
+	e.envPager.backIsSelected = false
+	e.envPager.pg.Page = row.env / e.envPager.pg.PerPage
+}
+
+// Switch between the table and the cards.
+func (v *Violet) toggleTable() {
+	if v.table != nil {
+		v.table = nil
+		return
+	}
+	v.table = &machineTable{}
+	rows := v.table.rows(&v.ecosystem)
+	if selectedRow(&v.ecosystem, rows) < 0 && len(rows) > 0 {
+		v.ecosystem.selectRow(rows[0])
+	}
+}
+
+// Handle the keys that work differently in the table, returning false for the rest.
+func (v *Violet) updateTable(msg tea.KeyMsg) bool {
+	rows := v.table.rows(&v.ecosystem)
+	selected := selectedRow(&v.ecosystem, rows)
+	switch {
+	case key.Matches(msg, v.keys.Up):
+		if len(rows) > 0 {
+			v.ecosystem.selectRow(rows[(max(selected, 0)+len(rows)-1)%len(rows)])
+		}
+	case key.Matches(msg, v.keys.Down):
+		if len(rows) > 0 {
+			v.ecosystem.selectRow(rows[(selected+1)%len(rows)])
+		}
+	case key.Matches(msg, v.keys.Sort):
+		v.table.sortColumn = (v.table.sortColumn + 1) % len(tableColumns)
+		v.table.descending = false
+	case key.Matches(msg, v.keys.SortReverse):
+		v.table.descending = !v.table.descending
+	case key.Matches(msg, v.keys.Tab), key.Matches(msg, v.keys.ShiftTab):
+		// There are no tabs
+	default:
+		return false
+	}
+	return true
+}
+
+// Cut s short enough for a cell, keeping the end of paths which is the interesting part.
+func truncateCell(s string, column int) string {
+	runes := []rune(s)
+	if len(runes) <= tableCellWidth {
+		return s
+	}
+	if tableColumns[column] == "HOME" {
+		return "…" + string(runes[len(runes)-tableCellWidth+1:])
+	}
+	return string(runes[:tableCellWidth-1]) + "…"
+}
+
+func (t *machineTable) View(e *Ecosystem) string {
+	if e.environments == nil {
+		return lipgloss.NewStyle().Foreground(textColor).Italic(true).Faint(true).Render("Still looking for environments...")
+	}
+	rows := t.rows(e)
+	selected := selectedRow(e, rows)
+
+	// Wide enough for every row, not only the ones shown, so scrolling doesn't shift them
+	widths := make([]int, len(tableColumns))
+	for i, column := range tableColumns {
+		widths[i] = lipgloss.Width(column) + 2
+	}
+	for _, row := range rows {
+		for i, cell := range row.cells {
+			widths[i] = max(widths[i], lipgloss.Width(truncateCell(cell, i)))
+		}
+	}
+	render := func(cells []string, style func(column int, cell string) string) string {
+		var line []string
+		for i, cell := range cells {
+			cell = truncateCell(cell, i)
+			padded := cell + strings.Repeat(" ", widths[i]-lipgloss.Width(cell))
+			line = append(line, style(i, padded))
+		}
+		return strings.Join(line, "  ")
+	}
+
+	header := slices.Clone(tableColumns)
+	if t.descending {
+		header[t.sortColumn] += " ▼"
+	} else {
+		header[t.sortColumn] += " ▲"
+	}
+	// Room for the mark in front of each row
+	lines := []string{panelItemStyle.Render("  " + render(header, func(_ int, cell string) string { return panelTitleStyle.Render(cell) }))}
+
+	// Keep the selected row in the middle
+	start := min(max(selected-tableRows/2, 0), max(len(rows)-tableRows, 0))
+	end := min(start+tableRows, len(rows))
+	for i, row := range rows[start:end] {
+		line := render(row.cells, func(column int, cell string) string {
+			if column == tableStateColumn {
+				return cardStatusStyle.Foreground(statusColor(row.cells[column])).Render(cell)
+			}
+			return cell
+		})
+		machine := &e.environments[row.env].machines[row.machine]
+		if e.isMarked(machine) {
+			line = markStyle.Render("● ") + line
+		} else {
+			line = "  " + line
+		}
+		if start+i == selected {
+			lines = append(lines, panelSelectedItemStyle.Render(line))
+		} else {
+			lines = append(lines, panelItemStyle.Render(line))
+		}
+	}
+
+	var footer []string
+	switch {
+	case len(rows) == 0 && e.filter != "":
+		footer = append(footer, panelHintStyle.Render("Nothing matches "+e.filter))
+	case len(rows) == 0:
+		footer = append(footer, panelHintStyle.Render("No machines yet"))
+	default:
+		footer = append(footer, panelHintStyle.Render(fmt.Sprintf("%v-%v of %v machines", start+1, end, len(rows))))
+	}
+	if selected >= 0 {
+		env := e.environments[rows[selected].env]
+		if env.hasFocus {
+			envCommands := newEnvCommandButtons(supportedEnvCommands)
+			footer = append(footer, lipgloss.JoinHorizontal(lipgloss.Center,
+				envCardTitleStyle.Render(env.name),
+				envCommands.View(env.selectedCommand, true)))
+		} else {
+			machine := env.machines[rows[selected].machine]
+			footer = append(footer, e.machineCommands.View(machine.selectedCommand, true))
+		}
+	}
+	footer = append(footer, panelHintStyle.Render(helpLine(keys.Sort, keys.SortReverse, keys.Table)))
+
+	return panelStyle.Render(lipgloss.JoinVertical(lipgloss.Left, strings.Join(lines, "\n"), "", lipgloss.JoinVertical(lipgloss.Left, footer...)))
+}
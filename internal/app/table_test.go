@@ -0,0 +1,97 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/braheezy/violet/pkg/vagrant"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	tableKey       = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("v")}
+	sortKey        = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("o")}
+	sortReverseKey = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("O")}
+	up             = tea.KeyMsg{Type: tea.KeyUp}
+)
+
+// The environment and machine name of each row, in order.
+func tableOrder(v Violet) []string {
+	var names []string
+	for _, row := range v.table.rows(&v.ecosystem) {
+		names = append(names, row.cells[0]+"/"+row.cells[1])
+	}
+	return names
+}
+
+func TestTable(t *testing.T) {
+	eco, err := createEcosystem(newTestEcosystem(3))
+	require.NoError(t, err)
+	v := press(newViolet(vagrant.NewSimulator()), ecosystemMsg(eco), tableKey)
+	require.NotNil(t, v.table)
+	assert.Equal(t, []string{"app01/web", "app01/db", "app02/web", "app02/db", "app03/web", "app03/db"}, tableOrder(v))
+	machine, err := v.ecosystem.currentMachine()
+	require.NoError(t, err)
+	assert.Equal(t, "web", machine.name, "Verify the first row is selected")
+
+	v = press(v, down, down)
+	assert.Equal(t, "app02", v.ecosystem.currentEnv().name, "Verify rows cross environments")
+	v = press(v, tab)
+	assert.Equal(t, "app02", v.ecosystem.currentEnv().name, "Verify there are no tabs to switch")
+	v = press(v, up, up, up)
+	assert.Equal(t, "app03", v.ecosystem.currentEnv().name, "Verify the rows wrap around")
+	assert.Equal(t, 1, v.ecosystem.selectedMachine)
+
+	// Sort by machine, then state
+	v = press(v, sortKey)
+	assert.Equal(t, []string{"app01/db", "app02/db", "app03/db", "app01/web", "app02/web", "app03/web"}, tableOrder(v))
+	v = press(v, sortKey, sortKey, sortReverseKey)
+	assert.Equal(t, []string{"app01/web", "app02/web", "app03/web", "app01/db", "app02/db", "app03/db"}, tableOrder(v), "Verify running comes before poweroff backwards")
+	assert.Equal(t, "app03", v.ecosystem.currentEnv().name, "Verify sorting keeps the selection")
+
+	// The command keys work on the selected row
+	v = press(v, right)
+	machine, err = v.ecosystem.currentMachine()
+	require.NoError(t, err)
+	assert.Equal(t, "db", machine.name)
+	assert.Equal(t, 1, machine.selectedCommand)
+	requireGoldenView(t, v)
+
+	v = press(v, tableKey)
+	assert.Nil(t, v.table)
+	assert.Equal(t, "app03", selectedTab(v.ecosystem), "Verify the cards show the selected machine")
+	assert.Equal(t, 1, v.ecosystem.selectedMachine)
+}
+
+func TestTableFromMoreTab(t *testing.T) {
+	eco, err := createEcosystem(newTestEcosystem(12))
+	require.NoError(t, err)
+	v := press(newViolet(vagrant.NewSimulator()), ecosystemMsg(eco))
+	v = press(v, repeat(tab, 5)...)
+	require.Equal(t, "more", selectedTab(v.ecosystem))
+
+	v = press(v, tableKey)
+	v = press(v, repeat(up, 2)...)
+	assert.Equal(t, "app12", v.ecosystem.currentEnv().name)
+	v = press(v, tableKey)
+	assert.Equal(t, 2, v.ecosystem.envPager.pg.Page, "Verify the cards page to the selected machine")
+	assert.Equal(t, "app12", selectedTab(v.ecosystem))
+}
+
+func TestThemePickerFromTable(t *testing.T) {
+	t.Cleanup(func() { setTheme(defaultDarkTheme) })
+	eco, err := createEcosystem(newTestEcosystem(2))
+	require.NoError(t, err)
+	v := press(newViolet(vagrant.NewSimulator()), ecosystemMsg(eco), tableKey)
+	v = press(v, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("t")})
+	require.NotNil(t, v.themes)
+	start := v.themes.selected
+
+	v = press(v, down, tab)
+	assert.Equal(t, start+1, v.themes.selected, "Verify the picker gets the keys, not the table")
+	machine, err := v.ecosystem.currentMachine()
+	require.NoError(t, err)
+	assert.Equal(t, "app01", v.ecosystem.currentEnv().name)
+	assert.Equal(t, "web", machine.name, "Verify the table selection didn't move")
+}
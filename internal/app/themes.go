@@ -0,0 +1,99 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/braheezy/violet/internal/config"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	tint "github.com/lrstanley/bubbletint"
+)
+
+// How many themes the picker lists at once
+const themePickerRows = 7
+
+// Keys used inside the theme picker.
+type themeKeyMap struct {
+	Up      key.Binding
+	Down    key.Binding
+	Preview key.Binding
+	Apply   key.Binding
+	Close   key.Binding
+}
+
+var themeKeys = newThemeKeyMap(config.DefaultKeys())
+
+func newThemeKeyMap(k config.Keys) themeKeyMap {
+	closing := newBinding(k, "cancel", "theme_close")
+	// The key that opened the picker closes it too
+	closing.SetKeys(append(closing.Keys(), k["themes"]...)...)
+	return themeKeyMap{
+		Up:      newBinding(k, "", "up"),
+		Down:    newBinding(k, "", "down"),
+		Preview: newBinding(k, "preview", "up", "down"),
+		Apply:   newBinding(k, "use", "theme_apply"),
+		Close:   closing,
+	}
+}
+
+// themePicker cycles through every built in theme, showing each one as it's selected.
+type themePicker struct {
+	tints    []tint.Tint
+	selected int
+	// The theme in use before the picker opened, to go back to
+	previous tint.Tint
+}
+
+// Open the theme picker at the theme in use.
+func (v *Violet) openThemePicker() {
+	tints := tint.DefaultTints()
+	selected := slices.IndexFunc(tints, func(t tint.Tint) bool { return t.ID() == theme.ID() })
+	v.themes = &themePicker{tints: tints, selected: max(selected, 0), previous: theme}
+}
+
+// Handle a key press while the theme picker is open.
+func (v *Violet) updateThemePicker(msg tea.KeyMsg) {
+	picker := v.themes
+	switch {
+	case key.Matches(msg, themeKeys.Apply):
+		v.themes = nil
+		v.notice = fmt.Sprintf("Using %v, set theme = %q in the config file to keep it", theme.DisplayName(), theme.ID())
+	case key.Matches(msg, themeKeys.Close):
+		setTheme(picker.previous)
+		v.themes = nil
+	case key.Matches(msg, themeKeys.Up):
+		picker.selected = (picker.selected + len(picker.tints) - 1) % len(picker.tints)
+		setTheme(picker.tints[picker.selected])
+	case key.Matches(msg, themeKeys.Down):
+		picker.selected = (picker.selected + 1) % len(picker.tints)
+		setTheme(picker.tints[picker.selected])
+	}
+}
+
+func (p *themePicker) View() string {
+	title := panelTitleStyle.Render(fmt.Sprintf("Theme %v of %v", p.selected+1, len(p.tints)))
+
+	// Keep the selected theme in the middle
+	start := min(max(p.selected-themePickerRows/2, 0), max(len(p.tints)-themePickerRows, 0))
+	end := min(start+themePickerRows, len(p.tints))
+	var rows []string
+	for i, t := range p.tints[start:end] {
+		if start+i == p.selected {
+			rows = append(rows, panelSelectedItemStyle.Render(t.DisplayName()))
+		} else {
+			rows = append(rows, panelItemStyle.Render(t.DisplayName()))
+		}
+	}
+
+	var swatches []string
+	for _, color := range []lipgloss.TerminalColor{theme.Red(), theme.Green(), theme.Yellow(), theme.Blue(), theme.Purple(), theme.Cyan()} {
+		swatches = append(swatches, lipgloss.NewStyle().Foreground(color).Render("██"))
+	}
+
+	footer := panelHintStyle.Render(helpLine(themeKeys.Preview, themeKeys.Apply, themeKeys.Close))
+	body := lipgloss.JoinVertical(lipgloss.Left, strings.Join(rows, "\n"), "", strings.Join(swatches, " "))
+	return panelStyle.Render(lipgloss.JoinVertical(lipgloss.Left, title, "", body, "", footer))
+}
@@ -0,0 +1,52 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/braheezy/violet/pkg/vagrant"
+	tea "github.com/charmbracelet/bubbletea"
+	tint "github.com/lrstanley/bubbletint"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThemePicker(t *testing.T) {
+	t.Cleanup(func() { setTheme(defaultDarkTheme) })
+	themeKey := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("t")}
+	up := tea.KeyMsg{Type: tea.KeyUp}
+	tints := tint.DefaultTints()
+
+	v := press(newViolet(vagrant.NewSimulator()), themeKey)
+	require.NotNil(t, v.themes)
+	start := v.themes.selected
+	assert.Equal(t, defaultDarkTheme.ID(), tints[start].ID(), "Verify the picker starts at the theme in use")
+
+	v = press(v, down)
+	assert.Equal(t, tints[start+1].ID(), theme.ID(), "Verify the theme is previewed")
+	assert.Contains(t, v.View(), tints[start+1].DisplayName())
+	v = press(v, tea.KeyMsg{Type: tea.KeyEsc})
+	assert.Nil(t, v.themes)
+	assert.Equal(t, defaultDarkTheme.ID(), theme.ID(), "Verify cancelling goes back to the old theme")
+
+	setTheme(tints[0])
+	v = press(v, themeKey, up)
+	assert.Equal(t, tints[len(tints)-1].ID(), theme.ID(), "Verify the themes wrap around")
+	v = press(v, down, down, enter)
+	assert.Nil(t, v.themes)
+	assert.Equal(t, tints[1].ID(), theme.ID(), "Verify the theme is kept")
+	assert.Contains(t, v.notice, tints[1].ID())
+}
+
+func TestStatusColors(t *testing.T) {
+	t.Cleanup(func() { setTheme(defaultDarkTheme) })
+	for _, palette := range []tint.Tint{defaultDarkTheme, defaultLightTheme} {
+		setTheme(palette)
+		assert.Equal(t, statusColor("poweroff"), statusColor("shutoff"), "Verify stopped looks the same on every provider")
+		assert.Equal(t, statusColor("not created"), statusColor("not_created"))
+		assert.NotEqual(t, statusColor("running"), statusColor("poweroff"))
+		assert.Equal(t, textColor, statusColor("confused"), "Verify unknown states are plain")
+		for state := range stateKinds {
+			assert.NotNil(t, statusColor(state), state)
+		}
+	}
+}
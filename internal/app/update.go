@@ -1,10 +1,15 @@
 package app
 
 import (
-	"math/rand"
-	"os/exec"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/braheezy/violet/internal/config"
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	zone "github.com/lrstanley/bubblezone"
 )
@@ -20,57 +25,115 @@ type helpKeyMap struct {
 	Execute       key.Binding
 	SelectCommand key.Binding
 	Space         key.Binding
+	Cancel        key.Binding
+	Snapshots     key.Binding
+	Details       key.Binding
+	Boxes         key.Binding
+	Refresh       key.Binding
+	Export        key.Binding
+	Ansible       key.Binding
+	SSHConfig     key.Binding
+	ScrollUp      key.Binding
+	ScrollDown    key.Binding
+	Filter        key.Binding
+	Table         key.Binding
+	Sort          key.Binding
+	SortReverse   key.Binding
+	Mark          key.Binding
+	ClearMarks    key.Binding
+	Themes        key.Binding
 	Help          key.Binding
 	Quit          key.Binding
 	// These are defined to assist with help text.
 	SelectMachine key.Binding
+	ScrollLog     key.Binding
 }
 
-// Setup the keybinding and help text for each key
-var keys = helpKeyMap{
-	SelectCommand: key.NewBinding(
-		key.WithKeys("left", "h", "right", "l"),
-		key.WithHelp("←/h →/l", "pick command"),
-	),
-	Up: key.NewBinding(
-		key.WithKeys("up", "k"),
-	),
-	Down: key.NewBinding(
-		key.WithKeys("down", "j"),
-	),
-	Left: key.NewBinding(
-		key.WithKeys("left", "h"),
-	),
-	Right: key.NewBinding(
-		key.WithKeys("right", "l"),
-	),
-	Tab: key.NewBinding(
-		key.WithKeys("tab"),
-		key.WithHelp("⭾/⇧+⭾", "switch env tab"),
-	),
-	ShiftTab: key.NewBinding(
-		key.WithKeys("shift+tab"),
-	),
-	Execute: key.NewBinding(
-		key.WithKeys("enter"),
-		key.WithHelp("⏎", "run"),
-	),
-	Space: key.NewBinding(
-		key.WithKeys(" "),
-		key.WithHelp("space", "toggle env/vm"),
-	),
-	Help: key.NewBinding(
-		key.WithKeys("?"),
-		key.WithHelp("?", "toggle help"),
-	),
-	Quit: key.NewBinding(
-		key.WithKeys("q", "esc", "ctrl+c"),
-		key.WithHelp("q", "quit"),
-	),
-	SelectMachine: key.NewBinding(
-		key.WithKeys("up", "k", "down", "j"),
-		key.WithHelp("↑/k ↓/j", "pick vm"),
-	),
+// The keybindings in use, see applySettings
+var keys = newHelpKeyMap(config.DefaultKeys())
+
+// Setup the keybinding and help text for each action, with the keys k says
+func newHelpKeyMap(k config.Keys) helpKeyMap {
+	// Help for both directions, but only for going forwards
+	tab := newBinding(k, "switch env tab", "next_tab", "prev_tab")
+	tab.SetKeys(k["next_tab"]...)
+	return helpKeyMap{
+		SelectCommand: newBinding(k, "pick command", "left", "right"),
+		Up:            newBinding(k, "", "up"),
+		Down:          newBinding(k, "", "down"),
+		Left:          newBinding(k, "", "left"),
+		Right:         newBinding(k, "", "right"),
+		Tab:           tab,
+		ShiftTab:      newBinding(k, "", "prev_tab"),
+		Execute:       newBinding(k, "run", "run"),
+		Space:         newBinding(k, "toggle env/vm", "toggle_focus"),
+		Cancel:        newBinding(k, "cancel run", "cancel"),
+		Snapshots:     newBinding(k, "snapshots", "snapshots"),
+		Details:       newBinding(k, "details", "details"),
+		Boxes:         newBinding(k, "boxes", "boxes"),
+		Refresh:       newBinding(k, "refresh", "refresh"),
+		Export:        newBinding(k, "export", "export"),
+		Ansible:       newBinding(k, "ansible inventory", "ansible"),
+		SSHConfig:     newBinding(k, "write ssh config", "ssh_config"),
+		ScrollUp:      newBinding(k, "", "scroll_up"),
+		ScrollDown:    newBinding(k, "", "scroll_down"),
+		Filter:        newBinding(k, "filter", "filter"),
+		Table:         newBinding(k, "table/cards", "table"),
+		Sort:          newBinding(k, "sort by next column", "sort"),
+		SortReverse:   newBinding(k, "reverse sort", "sort_reverse"),
+		Mark:          newBinding(k, "mark for bulk run", "mark"),
+		ClearMarks:    newBinding(k, "clear marks", "clear_marks"),
+		Themes:        newBinding(k, "themes", "themes"),
+		Help:          newBinding(k, "toggle help", "help"),
+		Quit:          newBinding(k, "quit", "quit"),
+		SelectMachine: newBinding(k, "pick vm", "up", "down"),
+		ScrollLog:     newBinding(k, "scroll output", "scroll_up", "scroll_down"),
+	}
+}
+
+// Create a binding for the keys of every action, with help showing them e.g. "↑/k ↓/j".
+// Without a description, it has no help.
+func newBinding(k config.Keys, desc string, actions ...string) key.Binding {
+	var bound, labels []string
+	// Actions with one key each are shown like "⭾/⇧+⭾", otherwise like "↑/k ↓/j"
+	separator := "/"
+	for _, action := range actions {
+		bound = append(bound, k[action]...)
+		labels = append(labels, keyLabel(k[action]))
+		if len(k[action]) > 1 {
+			separator = " "
+		}
+	}
+	binding := key.NewBinding(key.WithKeys(bound...))
+	if desc != "" {
+		binding.SetHelp(strings.Join(labels, separator), desc)
+	}
+	return binding
+}
+
+// Symbols for keys, to keep help short
+var keySymbols = map[string]string{
+	"up":        "↑",
+	"down":      "↓",
+	"left":      "←",
+	"right":     "→",
+	"enter":     "⏎",
+	"tab":       "⭾",
+	"shift+tab": "⇧+⭾",
+	" ":         "space",
+	"pgdown":    "pgdn",
+}
+
+// Show the first two keys in help, the rest would only crowd it.
+func keyLabel(keys []string) string {
+	var labels []string
+	for _, k := range keys[:min(len(keys), 2)] {
+		if symbol, ok := keySymbols[k]; ok {
+			k = symbol
+		}
+		labels = append(labels, k)
+	}
+	return strings.Join(labels, "/")
 }
 
 // ShortHelp returns keybindings to be shown in the mini help view. It's part
@@ -83,8 +146,10 @@ func (k helpKeyMap) ShortHelp() []key.Binding {
 // key.Map interface.
 func (k helpKeyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
-		{k.SelectMachine, k.SelectCommand, k.Tab}, // first column
-		{k.Space, k.Execute, k.Help, k.Quit},      // second column
+		{k.SelectMachine, k.SelectCommand, k.Tab, k.Filter, k.Table},   // first column
+		{k.Space, k.Execute, k.Cancel, k.Snapshots, k.Details, k.Mark}, // second column
+		{k.Refresh, k.Boxes, k.Export, k.Ansible},                      // third column
+		{k.SSHConfig, k.ScrollLog, k.Themes, k.Help, k.Quit},           // fourth column
 	}
 }
 
@@ -104,6 +169,7 @@ func (v Violet) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// If we set a width on the help menu it can it can gracefully truncate
 		// its view as needed.
 		v.help.Width = msg.Width
+		v.logPane.setWidth(msg.Width)
 		v.terminalWidth = msg.Width
 		v.terminalHeight = msg.Height
 
@@ -113,6 +179,17 @@ func (v Violet) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	// User moved the mouse
 	case tea.MouseMsg:
+		if zone.Get("log").InBounds(msg) {
+			return v, v.logPane.Update(msg)
+		}
+		// Show the full path of whichever environment tab is hovered
+		v.tooltip = ""
+		for _, env := range v.ecosystem.environments {
+			if zone.Get(env.zoneID()).InBounds(msg) {
+				v.tooltip = env.home
+				break
+			}
+		}
 		if tea.MouseEvent(msg).Action == tea.MouseActionRelease {
 			// Check if clicked More or Back tab
 			if zone.Get("more").InBounds(msg) {
@@ -124,7 +201,7 @@ func (v Violet) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			} else {
 				// Iterate over environment names
 				for i, env := range v.ecosystem.environments {
-					if zone.Get(env.name).InBounds(msg) {
+					if zone.Get(env.zoneID()).InBounds(msg) {
 						v.ecosystem.selectedEnv = i
 						v.ecosystem.envPager.moreIsSelected = false
 						v.ecosystem.envPager.backIsSelected = false
@@ -133,12 +210,47 @@ func (v Violet) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 		}
-		return v, nil
 
 	// User pressed a key
 	case tea.KeyMsg:
+		// A dialog takes all input until it's dealt with
+		if v.confirm != nil {
+			done, confirmed, cmd := v.confirm.Update(msg)
+			if done {
+				dialog := v.confirm
+				v.confirm = nil
+				if confirmed {
+					return v, dialog.onConfirm(&v)
+				}
+			}
+			return v, cmd
+		}
+		if v.snapshots != nil {
+			return v, v.updateSnapshotPanel(msg)
+		}
+		if v.details != nil {
+			return v, v.updateDetailsPane(msg)
+		}
+		if v.filtering {
+			return v, v.updateFilter(msg)
+		}
+		if v.themes != nil {
+			v.updateThemePicker(msg)
+			return v, nil
+		}
+		if v.table != nil && v.boxes == nil && v.updateTable(msg) {
+			return v, nil
+		}
+		if v.boxes != nil {
+			cmd := v.updateBoxScreen(msg)
+			v.logPane.show(v.currentOutput())
+			return v, cmd
+		}
 		switch {
 		case key.Matches(msg, v.keys.Left):
+			if !v.ecosystem.hasSelectedEnv() {
+				break
+			}
 			currentEnv := v.ecosystem.currentEnv()
 			currentMachine, err := v.ecosystem.currentMachine()
 			if err != nil {
@@ -159,6 +271,9 @@ func (v Violet) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 		case key.Matches(msg, v.keys.Right):
+			if !v.ecosystem.hasSelectedEnv() {
+				break
+			}
 			currentEnv := v.ecosystem.currentEnv()
 			currentMachine, err := v.ecosystem.currentMachine()
 			if err != nil {
@@ -179,7 +294,7 @@ func (v Violet) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 		case key.Matches(msg, v.keys.Up):
-			if v.ecosystem.currentEnv().hasFocus {
+			if !v.ecosystem.hasSelectedEnv() || v.ecosystem.currentEnv().hasFocus {
 				break
 			}
 			if v.ecosystem.selectedMachine == 0 {
@@ -188,7 +303,7 @@ func (v Violet) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				v.ecosystem.selectedMachine -= 1
 			}
 		case key.Matches(msg, v.keys.Down):
-			if v.ecosystem.currentEnv().hasFocus {
+			if !v.ecosystem.hasSelectedEnv() || v.ecosystem.currentEnv().hasFocus {
 				break
 			}
 			if v.ecosystem.selectedMachine == len(v.ecosystem.currentEnv().machines)-1 {
@@ -198,13 +313,15 @@ func (v Violet) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		case key.Matches(msg, v.keys.Tab):
 			v.ecosystem.incrementEnv()
-			return v, nil
 		case key.Matches(msg, v.keys.ShiftTab):
 			v.ecosystem.decrementEnv()
-			return v, nil
 		case key.Matches(msg, v.keys.Space):
+			if !v.ecosystem.hasSelectedEnv() {
+				break
+			}
 			v.ecosystem.currentEnv().hasFocus = !v.ecosystem.currentEnv().hasFocus
-			return v, nil
+		case key.Matches(msg, v.keys.ScrollUp), key.Matches(msg, v.keys.ScrollDown):
+			return v, v.logPane.Update(msg)
 		case key.Matches(msg, v.keys.Execute):
 			if v.ecosystem.envPager.moreIsSelected {
 				// User wants to see new env page
@@ -218,17 +335,29 @@ func (v Violet) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				_, end := v.ecosystem.envPager.pg.GetSliceBounds(len(v.ecosystem.environments))
 				v.ecosystem.selectedEnv = end - 1
 				v.ecosystem.envPager.backIsSelected = false
-			} else {
+			} else if v.ecosystem.hasSelectedEnv() {
 				if v.ecosystem.currentEnv().hasFocus {
-					vagrantCommand := supportedMachineCommands[v.ecosystem.currentEnv().selectedCommand]
-					runCommand := v.createEnvRunCmd(vagrantCommand, v.ecosystem.currentEnv().home)
-					v.spinner.show = true
-					// This must be sent for the spinner to spin
-					tickCmd := v.spinner.spinner.Tick
-					return v, tea.Batch(runCommand, tickCmd)
+					env := v.ecosystem.currentEnv()
+					vagrantCommand := supportedEnvCommands[env.selectedCommand]
+					run := func(v *Violet) tea.Cmd { return v.createEnvRunCmd(vagrantCommand, env) }
+					if destructiveCommands[vagrantCommand] {
+						// Affects every machine, so make them type it out
+						v.confirm = newConfirmDialog(
+							fmt.Sprintf("Really %v every machine in %v?", vagrantCommand, env.name),
+							env.name,
+							run,
+						)
+						return v, textinput.Blink
+					}
+					return v, run(&v)
 				} else {
 					currentMachine, _ := v.ecosystem.currentMachine()
 					vagrantCommand := supportedMachineCommands[currentMachine.selectedCommand]
+					// Marked machines all get the command, except ssh which only makes sense on one
+					if marked := v.ecosystem.markedMachines(); len(marked) > 0 && vagrantCommand != "ssh" {
+						v.confirmBulkRun(vagrantCommand, marked)
+						return v, nil
+					}
 					/*
 						TODO: This doesn't support running commands in a desktop-less environment that doesn't have an external terminal to put commands on. One approach is to use `screen` to create virtual screen.
 
@@ -239,10 +368,10 @@ func (v Violet) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					*/
 
 					if vagrantCommand == "ssh" {
-						c := exec.Command("vagrant", "ssh", currentMachine.machineID)
-						if currentMachine.provider == "docker" {
-							c = exec.Command("vagrant", "docker-exec", currentMachine.name, "-it", "--", "/bin/sh")
-							c.Dir = currentMachine.home
+						c, err := v.ecosystem.sshCommand(currentMachine)
+						if err != nil {
+							v.setErrorMessage(err.Error())
+							return v, nil
 						}
 						runCommand := tea.ExecProcess(c, func(err error) tea.Msg {
 							if err != nil {
@@ -252,18 +381,53 @@ func (v Violet) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						})
 						return v, runCommand
 					} else {
-						// Run the command async and stream result back
-						runCommand := v.createMachineRunCmd(
-							vagrantCommand,
-							currentMachine.machineID,
-						)
-						v.spinner.show = true
-						// This must be sent for the spinner to spin
-						tickCmd := v.spinner.spinner.Tick
-						return v, tea.Batch(runCommand, tickCmd)
+						// Run the command in the background and stream result back
+						env := v.ecosystem.currentEnv()
+						run := func(v *Violet) tea.Cmd { return v.createMachineRunCmd(vagrantCommand, env, currentMachine) }
+						if destructiveCommands[vagrantCommand] {
+							v.confirm = newConfirmDialog(
+								fmt.Sprintf("Really %v %v?", vagrantCommand, machineTarget(env, currentMachine).label),
+								"",
+								run,
+							)
+							return v, nil
+						}
+						return v, run(&v)
 					}
 				}
 			}
+		case key.Matches(msg, v.keys.Snapshots):
+			if v.ecosystem.hasSelectedEnv() && !v.ecosystem.currentEnv().hasFocus {
+				return v, v.openSnapshotPanel()
+			}
+		case key.Matches(msg, v.keys.Details):
+			if v.ecosystem.hasSelectedEnv() && !v.ecosystem.currentEnv().hasFocus {
+				return v, v.openDetailsPane()
+			}
+		case key.Matches(msg, v.keys.Refresh):
+			return v, v.startRefresh(true)
+		case key.Matches(msg, v.keys.Boxes):
+			return v, v.openBoxScreen()
+		case key.Matches(msg, v.keys.Export):
+			return v, v.createExportCmd()
+		case key.Matches(msg, v.keys.Ansible):
+			return v, v.createAnsibleExportCmd()
+		case key.Matches(msg, v.keys.SSHConfig):
+			v.confirmSSHConfigUpdate()
+		case key.Matches(msg, v.keys.Cancel):
+			v.jobs.cancelFor(v.ecosystem.currentLogTarget())
+			// Queued jobs are cancelled without ever finishing
+			v.finishBulkRuns()
+		case key.Matches(msg, v.keys.Mark):
+			v.ecosystem.toggleMark()
+		case key.Matches(msg, v.keys.ClearMarks):
+			v.ecosystem.marked = nil
+		case key.Matches(msg, v.keys.Table):
+			v.toggleTable()
+		case key.Matches(msg, v.keys.Filter):
+			return v, v.openFilter()
+		case key.Matches(msg, v.keys.Themes):
+			v.openThemePicker()
 		case key.Matches(msg, v.keys.Help):
 			v.help.ShowAll = !v.help.ShowAll
 		case key.Matches(msg, v.keys.Quit):
@@ -272,107 +436,204 @@ func (v Violet) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	// New data from `global-status` has come in
 	case ecosystemMsg:
-		eco := Ecosystem(msg)
-		var statusCmds []tea.Cmd
-		// Don't have the machine names yet, just machineIDs.
-		// Queue up a bunch of async calls to go get those names.
-		for _, env := range eco.environments {
-			for _, machine := range env.machines {
-				statusCmds = append(statusCmds, v.createNameStatusCmd(machine.machineID))
-			}
-		}
 		// Set the new ecosystem
-		v.ecosystem = eco
+		v.ecosystem = Ecosystem(msg)
+		v.ecosystem.setFilter(v.filterInput.Value())
+		v.lastRefreshed = time.Now()
 
-		return v, tea.Batch(statusCmds...)
+	case refreshTickMsg:
+		return v, tea.Batch(v.startRefresh(true), v.scheduleRefresh())
 
-	case nameStatusMsg:
-		// Find the machine this message is about
-		for i, env := range v.ecosystem.environments {
-			for j, machine := range env.machines {
-				if msg.machineID == machine.machineID {
-					v.ecosystem.environments[i].machines[j].name = msg.name
-				}
-			}
-		}
+	case machineIndexMsg:
+		// Vagrant changed something, the index already has the news so don't ask for status
+		return v, tea.Batch(v.startRefresh(false), waitForMachineIndex(v.indexChanges))
+
+	case ecosystemRefreshMsg:
+		return v, v.finishRefresh(msg)
 
 	// New data about a specific machine has come in
 	case machineStatusMsg:
-		v.spinner.show = false
-		v.spinner.verb = verbs[rand.Intn(len(verbs))]
-		v.spinner.spinner.Spinner = spinners[rand.Intn(len(spinners))]
-		// Find the machine this message is about
-		for i, env := range v.ecosystem.environments {
-			for j, machine := range env.machines {
-				if msg.identifier == machine.machineID || msg.identifier == machine.name {
-					// Found the machine this status message is about.
-					// Status msgs don't return some info so retain existing info
-					updateMachine := Machine{
-						machineID:       machine.machineID,
-						provider:        msg.status.Fields["provider-name"],
-						state:           msg.status.Fields["state"],
-						home:            machine.home,
-						name:            msg.status.Name,
-						selectedCommand: machine.selectedCommand,
+		v.ecosystem.change(func(environments []Environment) {
+			// Find the machine this message is about
+			for i, env := range environments {
+				for j, machine := range env.machines {
+					if msg.identifier == machine.machineID || msg.identifier == machine.name {
+						// Found the machine this status message is about.
+						// Status msgs don't return some info so retain existing info
+						updateMachine := Machine{
+							machineID:       machine.machineID,
+							provider:        msg.status.Fields["provider-name"],
+							state:           msg.status.Fields["state"],
+							home:            machine.home,
+							name:            msg.status.Name,
+							selectedCommand: machine.selectedCommand,
+						}
+						environments[i].machines[j] = updateMachine
 					}
-					v.ecosystem.environments[i].machines[j] = updateMachine
 				}
 			}
-		}
+		})
 
 	case envStatusMsg:
-		v.spinner.show = false
-		v.spinner.verb = verbs[rand.Intn(len(verbs))]
-		v.spinner.spinner.Spinner = spinners[rand.Intn(len(spinners))]
-
-		// Find the env this message is about
-		for i, env := range v.ecosystem.environments {
-			if msg.name == env.name {
-				selectedEnv := &v.ecosystem.environments[i]
-				newMachines := make([]Machine, 0)
-				for _, machineStatus := range msg.status {
-					newMachine := Machine{
-						provider: machineStatus.Fields["provider-name"],
-						state:    machineStatus.Fields["state"],
-						home:     selectedEnv.home,
-						name:     machineStatus.Name,
-						// Reset the selected command
-						selectedCommand: 0,
+		v.ecosystem.change(func(environments []Environment) {
+			// Find the env this message is about
+			for i, env := range environments {
+				if msg.home == env.home {
+					selectedEnv := &environments[i]
+					newMachines := make([]Machine, 0)
+					for _, machineStatus := range msg.status {
+						newMachine := Machine{
+							provider: machineStatus.Fields["provider-name"],
+							state:    strings.Replace(machineStatus.Fields["state"], "_", " ", -1),
+							home:     selectedEnv.home,
+							name:     machineStatus.Name,
+						}
+						// Env status doesn't include IDs, keep the ones we know so jobs can find the machine
+						for _, machine := range selectedEnv.machines {
+							if machine.name == newMachine.name {
+								newMachine.machineID = machine.machineID
+								newMachine.selectedCommand = machine.selectedCommand
+							}
+						}
+						newMachines = append(newMachines, newMachine)
 					}
-					newMachines = append(newMachines, newMachine)
+					selectedEnv.machines = newMachines
+					break
 				}
-				selectedEnv.machines = newMachines
-				break
 			}
+		})
+		if v.ecosystem.hasSelectedEnv() {
+			v.ecosystem.selectedMachine = min(v.ecosystem.selectedMachine, max(len(v.ecosystem.currentEnv().machines)-1, 0))
 		}
 		return v, nil
 
-	// Result from a command has been streamed in
-	case runMsg:
-		if v.ecosystem.currentEnv().hasFocus {
-			return v, v.createEnvStatusCmd(v.ecosystem.currentEnv())
+	// A line of output from a running job
+	case jobOutputMsg:
+		if j := v.jobs.get(msg.id); j != nil {
+			switch msg.line.Type {
+			case "error-exit":
+				j.vagrantError = msg.line.Text()
+				fallthrough
+			case "", "ui":
+				j.output = append(j.output, strings.Split(msg.line.Text(), "\n")...)
+			}
+		}
+		v.logPane.show(v.currentOutput())
+		return v, waitForJobOutput(msg.id, msg.stream)
+
+	// A job's command has finished
+	case jobDoneMsg:
+		j := v.jobs.get(msg.id)
+		if j == nil {
+			return v, nil
+		}
+		j.finish(msg.err)
+		if j.status == jobFailed {
+			v.setErrorMessage(fmt.Sprintf("%v on %v failed: %v", j.command, j.target.label, j.errorMessage()))
+		}
+		v.finishBulkRuns()
+		v.pruneJobs()
+		// Something happened so get new status on the target the command was
+		// run on, and make room for any waiting jobs.
+		cmds := []tea.Cmd{v.createTargetStatusCmd(j.target), v.jobs.startQueued(), v.forgetMachineDetails(j.target)}
+		if v.snapshots != nil && v.snapshots.target.key == j.target.key {
+			v.snapshots.loading = true
+			cmds = append(cmds, v.createSnapshotListCmd(v.snapshots.target))
+		}
+		return v, tea.Batch(cmds...)
+
+	case snapshotListMsg:
+		if v.snapshots != nil && v.snapshots.target.key == msg.key {
+			v.snapshots.loading = false
+			v.snapshots.snapshots = msg.snapshots
+			v.snapshots.err = ""
+			if msg.err != nil {
+				v.snapshots.err = msg.err.Error()
+			}
+			v.snapshots.selected = min(v.snapshots.selected, max(len(msg.snapshots)-1, 0))
+		}
+
+	case machineDetailsMsg:
+		v.machineDetails[msg.key] = msg.details
+		if v.details != nil && v.details.target.key == msg.key {
+			v.details.selectedPort = min(v.details.selectedPort, max(len(msg.details.ports)-1, 0))
+		}
+
+	case urlActionMsg:
+		if msg.err != nil {
+			v.setErrorMessage(msg.err.Error())
 		} else {
-			// Getting a runMsg means something happened so run async task to get
-			// new status on the machine the command was just run on.
-			currentMachine, _ := v.ecosystem.currentMachine()
-			return v, v.createMachineStatusCmd(currentMachine.machineID)
+			v.notice = msg.notice
+		}
+
+	case boxListMsg:
+		if v.boxes != nil {
+			v.boxes.loading = false
+			v.boxes.boxes = msg.boxes
+			v.boxes.err = ""
+			if msg.err != nil {
+				v.boxes.err = msg.err.Error()
+			}
+			v.boxes.selected = min(v.boxes.selected, max(len(msg.boxes)-1, 0))
 		}
 
+	case boxOutdatedMsg:
+		if v.boxes != nil {
+			v.boxes.checking = false
+			v.boxes.outdated = msg.outdated
+			if msg.err != nil {
+				v.boxes.hint = "Couldn't check for newer versions: " + msg.err.Error()
+			}
+		}
+
+	case boxPrunePreviewMsg:
+		v.confirmBoxPrune(msg)
+
+	case exportMsg:
+		v.finishExport(msg)
+
+	case spinner.TickMsg:
+		return v, v.jobs.updateSpinners(msg)
+
 	case ecosystemErrMsg:
 		v.setErrorMessage(msg.Error())
 	case statusErrMsg:
 		v.setErrorMessage(msg.Error())
 	case runErrMsg:
 		v.setErrorMessage(string(msg))
-	case nameStatusErrMsg:
-		v.setErrorMessage(msg.Error())
 	}
 
-	if v.spinner.show {
-		var spinCmd tea.Cmd
-		v.spinner.spinner, spinCmd = v.spinner.spinner.Update(msg)
-		return v, spinCmd
-	}
+	// Keep the log pane on whatever is selected now
+	v.logPane.show(v.currentOutput())
 
 	return v, nil
 }
+
+// The log target of the current selection and the output of its latest job.
+func (v *Violet) currentOutput() (string, []string) {
+	target := v.ecosystem.currentLogTarget()
+	if v.boxes != nil {
+		target = boxesTarget.key
+	}
+	if j := v.jobs.latestFor(target); j != nil {
+		return target, j.output
+	}
+	return target, nil
+}
+
+// Forget old finished jobs, keeping the ones a bulk run is waiting on and the one whose output is shown.
+func (v *Violet) pruneJobs() {
+	target, _ := v.currentOutput()
+	shown := v.jobs.latestFor(target)
+	v.jobs.prune(func(j *job) bool {
+		if j == shown {
+			return true
+		}
+		for _, bulk := range v.bulkRuns {
+			if slices.Contains(bulk.jobs, j.id) {
+				return true
+			}
+		}
+		return false
+	})
+}
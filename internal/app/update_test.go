@@ -0,0 +1,282 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/braheezy/violet/internal/config"
+	"github.com/braheezy/violet/pkg/vagrant"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/exp/teatest"
+	zone "github.com/lrstanley/bubblezone"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Golden files are rendered at this terminal size
+const (
+	testWidth  = 100
+	testHeight = 50
+)
+
+var (
+	tab      = tea.KeyMsg{Type: tea.KeyTab}
+	shiftTab = tea.KeyMsg{Type: tea.KeyShiftTab}
+	enter    = tea.KeyMsg{Type: tea.KeyEnter}
+	space    = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")}
+	down     = tea.KeyMsg{Type: tea.KeyDown}
+	right    = tea.KeyMsg{Type: tea.KeyRight}
+)
+
+// Simulate envs environments, app01, app02..., each with a web and a db machine.
+func newTestEcosystem(envs int) *vagrant.Simulator {
+	var machines []vagrant.SimulatedMachine
+	for i := 1; i <= envs; i++ {
+		home := fmt.Sprintf("/projects/app%02d", i)
+		machines = append(machines,
+			vagrant.SimulatedMachine{ID: fmt.Sprintf("a%02d0000", i), Name: "web", Provider: "virtualbox", State: "running", Home: home},
+			vagrant.SimulatedMachine{ID: fmt.Sprintf("b%02d0000", i), Name: "db", Provider: "virtualbox", State: "poweroff", Home: home},
+		)
+	}
+	return vagrant.NewSimulator(machines...)
+}
+
+// A Runner that can't find any machines.
+type brokenRunner struct{ *vagrant.Simulator }
+
+func (brokenRunner) GlobalStatus(context.Context) ([]vagrant.GlobalStatusEntry, error) {
+	return nil, errors.New("the machine index is corrupt")
+}
+
+// Start Violet running Vagrant with runner and wait until it's shown what it found.
+func startViolet(t *testing.T, runner vagrant.Runner, ready string) *teatest.TestModel {
+	t.Helper()
+	v := newViolet(runner)
+	v.refreshInterval = 0
+	tm := teatest.NewTestModel(t, v, teatest.WithInitialTermSize(testWidth, testHeight))
+	teatest.WaitFor(t, tm.Output(), func(out []byte) bool {
+		return bytes.Contains(out, []byte(ready))
+	}, teatest.WithDuration(3*time.Second))
+	return tm
+}
+
+// Stop Violet and return the model it ended with.
+func stopViolet(t *testing.T, tm *teatest.TestModel) Violet {
+	t.Helper()
+	require.NoError(t, tm.Quit())
+	return tm.FinalModel(t, teatest.WithFinalTimeout(3*time.Second)).(Violet)
+}
+
+// Compare what v shows with the golden file for the test, see -update.
+func requireGoldenView(t *testing.T, v Violet) {
+	t.Helper()
+	// Pin the clock so the golden files don't change
+	if !v.lastRefreshed.IsZero() {
+		v.lastRefreshed = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	}
+	teatest.RequireEqualOutput(t, []byte(v.View()))
+}
+
+// Which tab is selected
+func selectedTab(e Ecosystem) string {
+	switch {
+	case e.envPager.moreIsSelected:
+		return "more"
+	case e.envPager.backIsSelected:
+		return "back"
+	}
+	return e.currentEnv().name
+}
+
+// Update v with each message in turn, without running any commands.
+func press(v Violet, msgs ...tea.Msg) Violet {
+	for _, msg := range msgs {
+		model, _ := v.Update(msg)
+		v = model.(Violet)
+	}
+	return v
+}
+
+func repeat(msg tea.Msg, n int) []tea.Msg {
+	msgs := make([]tea.Msg, n)
+	for i := range msgs {
+		msgs[i] = msg
+	}
+	return msgs
+}
+
+func TestEnvironmentPaging(t *testing.T) {
+	// Three pages: app01-app05, app06-app10 and app11-app12
+	many, err := createEcosystem(newTestEcosystem(12))
+	require.NoError(t, err)
+	few, err := createEcosystem(newTestEcosystem(3))
+	require.NoError(t, err)
+	// Get to the middle page
+	toPage2 := slices.Concat(repeat(tab, 5), []tea.Msg{enter})
+	toPage3 := slices.Concat(toPage2, repeat(tab, 5), []tea.Msg{enter})
+
+	tests := []struct {
+		name      string
+		ecosystem Ecosystem
+		keys      []tea.Msg
+		tab       string
+		page      int
+	}{
+		{"Verify Tab reaches the More tab", many, repeat(tab, 5), "more", 0},
+		{"Verify Tab wraps from More on the first page", many, repeat(tab, 6), "app01", 0},
+		{"Verify Shift+Tab wraps to More on the first page", many, []tea.Msg{shiftTab}, "more", 0},
+		{"Verify Shift+Tab leaves the More tab", many, []tea.Msg{shiftTab, shiftTab}, "app05", 0},
+		{"Verify Enter on More shows the next page", many, toPage2, "app06", 1},
+		{"Verify Tab on a middle page reaches More", many, slices.Concat(toPage2, repeat(tab, 5)), "more", 1},
+		{"Verify Tab wraps from More to Back on a middle page", many, slices.Concat(toPage2, repeat(tab, 6)), "back", 1},
+		{"Verify Tab leaves the Back tab", many, slices.Concat(toPage2, repeat(tab, 7)), "app06", 1},
+		{"Verify Shift+Tab reaches Back on a middle page", many, slices.Concat(toPage2, []tea.Msg{shiftTab}), "back", 1},
+		{"Verify Shift+Tab wraps from Back to More on a middle page", many, slices.Concat(toPage2, []tea.Msg{shiftTab, shiftTab}), "more", 1},
+		{"Verify Enter on More shows the last page", many, toPage3, "app11", 2},
+		{"Verify the last page wraps to Back", many, slices.Concat(toPage3, []tea.Msg{tab, tab}), "back", 2},
+		{"Verify Shift+Tab wraps from Back on the last page", many, slices.Concat(toPage3, []tea.Msg{shiftTab, shiftTab}), "app12", 2},
+		{"Verify Enter on Back shows the previous page", many, slices.Concat(toPage3, []tea.Msg{shiftTab, enter}), "app10", 1},
+		{"Verify Tab wraps on a single page", few, repeat(tab, 3), "app01", 0},
+		{"Verify Shift+Tab wraps on a single page", few, []tea.Msg{shiftTab}, "app03", 0},
+	}
+
+	for _, test := range tests {
+		v := newViolet(vagrant.NewSimulator())
+		v = press(v, ecosystemMsg(test.ecosystem))
+		v = press(v, test.keys...)
+		assert.Equal(t, test.tab, selectedTab(v.ecosystem), test.name)
+		assert.Equal(t, test.page, v.ecosystem.envPager.pg.Page, test.name)
+	}
+}
+
+func TestPagingTabsIgnoreMachineKeys(t *testing.T) {
+	eco, err := createEcosystem(newTestEcosystem(12))
+	require.NoError(t, err)
+	v := press(newViolet(vagrant.NewSimulator()), ecosystemMsg(eco))
+	v = press(v, repeat(tab, 5)...)
+
+	// None of these have an environment to act on
+	v = press(v, space, down, right, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	assert.Equal(t, "more", selectedTab(v.ecosystem))
+	assert.Empty(t, v.errorMessage)
+	assert.Nil(t, v.snapshots)
+}
+
+func TestRemappedKeys(t *testing.T) {
+	cfg := config.Default()
+	cfg.Keys["quit"] = []string{"ctrl+q"}
+	cfg.Keys["next_tab"] = []string{"n"}
+	cfg.Keys["snapshot_close"] = []string{"x"}
+	require.NoError(t, cfg.Validate())
+	applySettings(cfg)
+	t.Cleanup(func() { applySettings(config.Default()) })
+
+	eco, err := createEcosystem(newTestEcosystem(3))
+	require.NoError(t, err)
+	v := press(newViolet(vagrant.NewSimulator()), ecosystemMsg(eco))
+	v = press(v, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	assert.Equal(t, "app02", selectedTab(v.ecosystem))
+	v = press(v, tab)
+	assert.Equal(t, "app02", selectedTab(v.ecosystem), "Verify the old key doesn't do anything")
+
+	_, cmd := v.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	assert.Nil(t, cmd, "Verify q doesn't quit")
+	_, cmd = v.Update(tea.KeyMsg{Type: tea.KeyCtrlQ})
+	require.NotNil(t, cmd)
+	assert.Equal(t, tea.Quit(), cmd())
+
+	helpView := v.help.View(v.keys)
+	assert.Contains(t, helpView, "ctrl+q")
+	assert.Contains(t, helpView, "n/⇧+⭾")
+	assert.Equal(t, "x close", helpLine(snapshotKeys.Close), "Verify the panel's help shows the new keys")
+	assert.True(t, key.Matches(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")}, snapshotKeys.Close), "Verify the panel still closes with the key that opened it")
+}
+
+func TestViewPaging(t *testing.T) {
+	tm := startViolet(t, newTestEcosystem(12), "app05")
+	for _, msg := range slices.Concat(repeat(tab, 5), []tea.Msg{enter, tab}) {
+		tm.Send(msg)
+	}
+	v := stopViolet(t, tm)
+
+	assert.Equal(t, "app07", selectedTab(v.ecosystem))
+	assert.Equal(t, 1, v.ecosystem.envPager.pg.Page)
+	requireGoldenView(t, v)
+}
+
+func TestViewMoreTab(t *testing.T) {
+	tm := startViolet(t, newTestEcosystem(12), "app05")
+	for _, msg := range repeat(tab, 5) {
+		tm.Send(msg)
+	}
+	v := stopViolet(t, tm)
+
+	assert.Equal(t, "more", selectedTab(v.ecosystem))
+	requireGoldenView(t, v)
+}
+
+func TestViewFocus(t *testing.T) {
+	tm := startViolet(t, newTestEcosystem(2), "app02")
+	// Focus the machines, then pick the second one and its second command
+	for _, msg := range []tea.Msg{space, down, right} {
+		tm.Send(msg)
+	}
+	v := stopViolet(t, tm)
+
+	env := v.ecosystem.currentEnv()
+	assert.False(t, env.hasFocus)
+	assert.Equal(t, 1, v.ecosystem.selectedMachine)
+	assert.Equal(t, 1, env.machines[1].selectedCommand)
+	assert.Equal(t, 0, env.selectedCommand, "Verify the environment's command is left alone")
+	requireGoldenView(t, v)
+
+	// And back to the environment
+	v = press(v, space)
+	assert.True(t, v.ecosystem.currentEnv().hasFocus)
+}
+
+func TestViewError(t *testing.T) {
+	tm := startViolet(t, brokenRunner{vagrant.NewSimulator()}, "corrupt")
+	v := stopViolet(t, tm)
+
+	assert.Equal(t, "the machine index is corrupt", v.errorMessage)
+	assert.Nil(t, v.ecosystem.environments)
+	requireGoldenView(t, v)
+}
+
+func TestMouse(t *testing.T) {
+	eco, err := createEcosystem(newTestEcosystem(12))
+	require.NoError(t, err)
+	v := press(newViolet(vagrant.NewSimulator()), tea.WindowSizeMsg{Width: testWidth, Height: testHeight}, ecosystemMsg(eco))
+
+	// Where things are is only known once they've been shown
+	at := func(id string) tea.MouseMsg {
+		t.Helper()
+		v.View()
+		require.Eventually(t, func() bool { return !zone.Get(id).IsZero() }, time.Second, 10*time.Millisecond, id)
+		z := zone.Get(id)
+		return tea.MouseMsg{X: z.StartX, Y: z.StartY, Button: tea.MouseButtonNone, Action: tea.MouseActionMotion}
+	}
+	click := func(msg tea.MouseMsg) tea.MouseMsg {
+		msg.Button = tea.MouseButtonLeft
+		msg.Action = tea.MouseActionRelease
+		return msg
+	}
+
+	home := eco.environments[2].home
+	v = press(v, at(eco.environments[2].zoneID()))
+	assert.Equal(t, home, v.tooltip, "Verify hovering a tab shows where the environment is")
+
+	v = press(v, click(at(eco.environments[2].zoneID())))
+	assert.Equal(t, "app03", selectedTab(v.ecosystem))
+
+	v = press(v, click(at("more")), enter)
+	assert.Equal(t, "app06", selectedTab(v.ecosystem))
+	assert.Equal(t, 1, v.ecosystem.envPager.pg.Page)
+}
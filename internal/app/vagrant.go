@@ -1,57 +1,87 @@
 package app
 
 import (
-	"fmt"
+	"context"
 	"log"
 	"strings"
 
+	"github.com/braheezy/violet/internal/config"
 	"github.com/braheezy/violet/pkg/vagrant"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-// Order matters here.
-var supportedMachineCommands = []string{"up", "halt", "ssh", "reload", "provision"}
-var supportedEnvCommands = []string{"up", "halt", "reload", "provision"}
+// Order matters here. The config decides which are shown, see applySettings.
+var supportedMachineCommands = config.Default().MachineCommands
+var supportedEnvCommands = config.Default().EnvCommands
 var symbols = map[string]string{
 	"up":        "▶",
 	"halt":      "■",
 	"ssh":       "＞＿ssh",
 	"reload":    "↺",
 	"provision": "🛠",
+	"suspend":   "⏸",
+	"resume":    "⏯",
+	"destroy":   "✖",
 }
 
-// runMsg is emitted after a command is run.
-type runMsg struct {
-	content string
+// Commands that can't be undone, so the user has to confirm them first.
+var destructiveCommands = map[string]bool{
+	"destroy": true,
 }
-type runErrMsg string
-
-// Create the tea.Cmd that will run command on the machine specified by identifier.
-func (v *Violet) createMachineRunCmd(command string, identifier string) tea.Cmd {
-	return func() tea.Msg {
-		log.Printf("Running %v on %v", command, identifier)
-		content, err := v.ecosystem.client.RunCommand(fmt.Sprintf("%v %v", command, identifier))
 
-		if err != nil {
-			return runErrMsg(vagrant.ParseVagrantError(err.Error()))
-		}
+// Extra arguments needed to run commands without Vagrant prompting for input.
+var commandArgs = map[string]string{
+	"destroy": "--force",
+}
 
-		return runMsg{content: content}
+// The full Vagrant command line for command, ready for a job to run.
+func vagrantArgs(command string, target string) []string {
+	args := []string{command}
+	if target != "" {
+		args = append(args, target)
 	}
+	if extra, ok := commandArgs[command]; ok {
+		args = append(args, extra)
+	}
+	return append(args, "--machine-readable")
 }
 
-// Create the tea.Cmd that will run command in the directory.
-func (v *Violet) createEnvRunCmd(command string, dir string) tea.Cmd {
-	return func() tea.Msg {
-		log.Printf("Running %v in %v", command, dir)
-		content, err := v.ecosystem.client.RunCommandInDirectory(command, dir)
+type runErrMsg string
 
-		if err != nil {
-			return runErrMsg(vagrant.ParseVagrantError(err.Error()))
+// Queue the job that will run command on machine.
+func (v *Violet) createMachineRunCmd(command string, env *Environment, machine *Machine) tea.Cmd {
+	client := v.ecosystem.client
+	target := machineTarget(env, machine)
+	return v.jobs.submit(target, command, func(ctx context.Context) *vagrant.Stream {
+		if target.machineID != "" {
+			log.Printf("Running %v on %v", command, target.machineID)
+			return client.Stream(ctx, "", vagrantArgs(command, target.machineID)...)
 		}
+		// Machines Vagrant hasn't indexed yet can only be found by name from their environment
+		log.Printf("Running %v on %v in %v", command, target.machineName, target.home)
+		return client.Stream(ctx, target.home, vagrantArgs(command, target.machineName)...)
+	})
+}
 
-		return runMsg{content: content}
+// Queue the job that will run command on the whole environment.
+func (v *Violet) createEnvRunCmd(command string, env *Environment) tea.Cmd {
+	client := v.ecosystem.client
+	target := envTarget(env)
+	return v.jobs.submit(target, command, func(ctx context.Context) *vagrant.Stream {
+		log.Printf("Running %v in %v", command, target.home)
+		return client.Stream(ctx, target.home, vagrantArgs(command, "")...)
+	})
+}
+
+// Create the tea.Cmd that gets fresh status for whatever target a job ran on.
+func (v *Violet) createTargetStatusCmd(target jobTarget) tea.Cmd {
+	if target.key == boxesTarget.key {
+		return v.createBoxListCmd()
+	}
+	if target.machineID != "" {
+		return v.createMachineStatusCmd(target.machineID)
 	}
+	return v.createEnvStatusCmd(target.home)
 }
 
 // machineStatusMsg is emitted when status on a machine is received.
@@ -68,9 +98,10 @@ func (e statusErrMsg) Error() string { return e.err.Error() }
 
 // Create the tea.Cmd that will get status on a machine.
 func (v *Violet) createMachineStatusCmd(identifier string) tea.Cmd {
+	client := v.ecosystem.client
 	return func() tea.Msg {
 		log.Printf("Getting status for %v", identifier)
-		result, err := v.ecosystem.client.GetStatusForID(identifier)
+		result, err := client.Status(context.Background(), "", identifier)
 
 		if err != nil {
 			return statusErrMsg{err}
@@ -86,45 +117,19 @@ func (v *Violet) createMachineStatusCmd(identifier string) tea.Cmd {
 	}
 }
 
-type nameStatusMsg struct {
-	machineID string
-	name      string
-}
-
-type nameStatusErrMsg struct{ err error }
-
-func (e nameStatusErrMsg) Error() string { return e.err.Error() }
-
-// Create the tea.Cmd that will get name of a machine.
-func (v *Violet) createNameStatusCmd(identifier string) tea.Cmd {
-	return func() tea.Msg {
-		log.Printf("Getting status for %v", identifier)
-		result, err := v.ecosystem.client.GetStatusForID(identifier)
-
-		if err != nil {
-			return nameStatusErrMsg{err}
-		}
-
-		machineStatus := vagrant.ParseVagrantOutput(result)[0]
-
-		return nameStatusMsg{
-			machineID: identifier,
-			name:      machineStatus.Name,
-		}
-	}
-}
-
 // envStatusMsg is emitted when status on an environment is received.
 type envStatusMsg struct {
-	name   string
+	// home identifies the environment
+	home   string
 	status []vagrant.MachineInfo
 }
 
-// Create the tea.Cmd that will get status on an environment.
-func (v *Violet) createEnvStatusCmd(env *Environment) tea.Cmd {
+// Create the tea.Cmd that will get status on the environment in home.
+func (v *Violet) createEnvStatusCmd(home string) tea.Cmd {
+	client := v.ecosystem.client
 	return func() tea.Msg {
-		log.Printf("Getting status in %v", env.home)
-		result, err := v.ecosystem.client.RunCommandInDirectory("status --machine-readable", env.home)
+		log.Printf("Getting status in %v", home)
+		result, err := client.Status(context.Background(), home, "")
 
 		if err != nil {
 			return statusErrMsg{err}
@@ -132,7 +137,7 @@ func (v *Violet) createEnvStatusCmd(env *Environment) tea.Cmd {
 
 		newStatus := vagrant.ParseVagrantOutput(result)
 		return envStatusMsg{
-			name:   env.name,
+			home:   home,
 			status: newStatus,
 		}
 	}
@@ -1,8 +1,6 @@
 package app
 
 import (
-	"fmt"
-
 	"github.com/charmbracelet/lipgloss"
 	zone "github.com/lrstanley/bubblezone"
 )
@@ -20,35 +18,56 @@ func (v Violet) View() (view string) {
 		Render(help)
 	view += lipgloss.PlaceHorizontal(v.terminalWidth, lipgloss.Center, helpText)
 	view += "\n"
+	if filter := v.filterView(); filter != "" {
+		view += lipgloss.PlaceHorizontal(v.terminalWidth, lipgloss.Center, filter)
+		view += "\n\n"
+	}
+	if marks := v.marksView(); marks != "" {
+		view += lipgloss.PlaceHorizontal(v.terminalWidth, lipgloss.Center, marks)
+		view += "\n\n"
+	}
 
-	// Show the current environments
+	// Show the current environments, unless a dialog needs the user's attention
 	ecosystemView := v.ecosystem.View()
+	if v.table != nil {
+		ecosystemView = v.table.View(&v.ecosystem)
+	}
+	if v.boxes != nil {
+		ecosystemView = v.boxes.View()
+	}
+	if overlay := v.overlayView(); overlay != "" {
+		ecosystemView = lipgloss.Place(
+			lipgloss.Width(ecosystemView),
+			lipgloss.Height(ecosystemView),
+			lipgloss.Center,
+			lipgloss.Center,
+			overlay,
+		)
+	}
 	view += lipgloss.PlaceHorizontal(v.terminalWidth, lipgloss.Center, ecosystemView)
-	view += "\n\n"
+	view += "\n"
+	tooltip := v.tooltip
+	if tooltip == "" {
+		tooltip = v.notice
+	}
+	view += lipgloss.PlaceHorizontal(v.terminalWidth, lipgloss.Center, tooltipStyle.Render(tooltip))
+	view += "\n"
+	view += lipgloss.PlaceHorizontal(v.terminalWidth, lipgloss.Center, v.refreshView())
+	view += "\n"
+
+	if jobsView := v.jobs.View(); jobsView != "" {
+		view += jobsView + "\n\n"
+	}
+
+	if v.logPane.hasOutput() {
+		view += zone.Mark("log", v.logPane.View(v.logPaneTitle()))
+		view += "\n\n"
+	}
 
 	if len(v.errorMessage) > 0 {
 		view += errorTitleStyle.Render("Violet ran into an error: ")
 		view += "\n"
 		view += errorStyle.Render(v.errorMessage)
-	} else if v.spinner.show {
-		currentMachine, _ := v.ecosystem.currentMachine()
-		commandIndex := currentMachine.selectedCommand
-		targetName := currentMachine.name
-		if v.ecosystem.currentEnv().hasFocus {
-			commandIndex = v.ecosystem.currentEnv().selectedCommand
-			targetName = v.ecosystem.currentEnv().name
-		}
-		command := spinnerCommandStyle.Render(supportedMachineCommands[commandIndex])
-
-		title := spinnerStyle.Render(fmt.Sprintf(
-			"%v: %v command %v",
-			targetName,
-			v.spinner.verb,
-			command,
-		))
-
-		progressView := fmt.Sprintf("%v %v %v\n\n", v.spinner.spinner.View(), title, v.spinner.spinner.View())
-		view += lipgloss.NewStyle().Margin(marginVertical, marginHorizontal).Render(progressView)
 	}
 
 	// Monitor mouse zones and strip injected ANSI sequences
@@ -56,3 +75,35 @@ func (v Violet) View() (view string) {
 
 	return view
 }
+
+// Name of whatever the log pane is showing output for
+func (v Violet) logPaneTitle() string {
+	if v.boxes != nil {
+		return "boxes"
+	}
+	if !v.ecosystem.hasSelectedEnv() {
+		return ""
+	}
+	if v.ecosystem.currentEnv().hasFocus {
+		return v.ecosystem.currentEnv().name
+	}
+	if machine, err := v.ecosystem.currentMachine(); err == nil && machine.name != "" {
+		return machine.name
+	}
+	return "machine"
+}
+
+// The dialog or panel covering the ecosystem, if one is open
+func (v Violet) overlayView() string {
+	switch {
+	case v.confirm != nil:
+		return v.confirm.View()
+	case v.snapshots != nil:
+		return v.snapshots.View()
+	case v.details != nil:
+		return v.details.View(v.machineDetails[v.details.target.key])
+	case v.themes != nil:
+		return v.themes.View()
+	}
+	return ""
+}
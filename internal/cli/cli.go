@@ -0,0 +1,189 @@
+// Package cli is Violet without the TUI: subcommands that print tables,
+// JSON or YAML and exit with codes scripts can rely on.
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/braheezy/violet/internal/config"
+	"github.com/braheezy/violet/internal/inventory"
+	"github.com/braheezy/violet/pkg/vagrant"
+)
+
+// Exit codes
+const (
+	exitOK = 0
+	// Vagrant ran and failed
+	exitFailed = 1
+	// The command line was wrong, or a selector didn't match anything
+	exitUsage = 2
+)
+
+// A subcommand e.g. `violet list`.
+type command struct {
+	name string
+	// The arguments it takes, for the usage
+	args    string
+	summary string
+	run     func(c *cli, args []string) int
+}
+
+var commands = []command{
+	{"list", "[--format table|json|yaml] [--ssh]", "List every environment and machine", runList},
+	{"status", "[--format table|json|yaml] <selector>...", "Ask Vagrant for the current state of machines", runStatus},
+	{"up", "<selector>...", "Start machines", runVagrant("up")},
+	{"halt", "<selector>...", "Stop machines", runVagrant("halt")},
+	{"reload", "<selector>...", "Restart machines", runVagrant("reload")},
+	{"ssh", "<machine> [-- <ssh args>...]", "SSH into a machine", runSSH},
+	{"ansible", "[--format ini|yaml]", "Print an Ansible inventory of the running machines", runAnsible},
+	{"ssh-config", "[--write [--file <path>]]", "Print or update an SSH config for the running machines", runSSHConfig},
+}
+
+// Run the subcommand in args with Vagrant, set up the way cfg says, and return the exit code.
+func Run(cfg config.Config, args []string) int {
+	client, err := cfg.NewVagrantClient()
+	if err != nil && !wantsHelp(args) {
+		fmt.Fprintln(os.Stderr, "violet:", err)
+		return exitFailed
+	}
+	// Interrupting stops Vagrant gracefully instead of leaving it half done
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	return run(ctx, client, args, os.Stdout, os.Stderr)
+}
+
+// The state of one run of the CLI.
+type cli struct {
+	ctx    context.Context
+	runner vagrant.Runner
+	stdout io.Writer
+	stderr io.Writer
+	// The subcommand being run
+	command command
+}
+
+func run(ctx context.Context, runner vagrant.Runner, args []string, stdout io.Writer, stderr io.Writer) int {
+	c := &cli{ctx: ctx, runner: runner, stdout: stdout, stderr: stderr}
+	if len(args) == 0 {
+		c.usage(stderr)
+		return exitUsage
+	}
+	if wantsHelp(args) {
+		c.usage(stdout)
+		return exitOK
+	}
+	for _, command := range commands {
+		if command.name == args[0] {
+			c.command = command
+			return command.run(c, args[1:])
+		}
+	}
+	fmt.Fprintf(stderr, "violet: unknown command %q\n\n", args[0])
+	c.usage(stderr)
+	return exitUsage
+}
+
+// Help doesn't need Vagrant
+func wantsHelp(args []string) bool {
+	return len(args) > 0 && slices.Contains([]string{"help", "-h", "-help", "--help"}, args[0])
+}
+
+func (c *cli) usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	tw := tabwriter.NewWriter(w, 0, 4, 3, ' ', 0)
+	fmt.Fprintln(tw, "  violet [--theme <id>]\tManage machines interactively, in any bubbletint theme")
+	for _, command := range commands {
+		fmt.Fprintf(tw, "  violet %v %v\t%v\n", command.name, command.args, command.summary)
+	}
+	tw.Flush()
+	fmt.Fprintln(w, `
+A selector is an environment's name or path, <environment>/<machine>,
+a machine ID (or the start of one) or a machine name only one environment has.
+See the names with: violet list
+
+Exit codes: 0 on success, 1 when Vagrant fails, 2 when the command line is wrong
+or a selector doesn't match anything.`)
+}
+
+// Create the flags for the subcommand, with its usage.
+func (c *cli) flags() *flag.FlagSet {
+	fs := flag.NewFlagSet("violet "+c.command.name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(c.stderr, "Usage: violet %v %v\n\n%v.\n", c.command.name, c.command.args, c.command.summary)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// Parse args with fs, allowing flags after positional arguments e.g. `violet status web --format json`.
+// Everything after -- is positional.
+func parse(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		// Parsing stops at --, which isn't left in rest
+		if parsed := len(args) - len(rest); parsed > 0 && args[parsed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// Report a problem with the command line.
+func (c *cli) usageError(format string, a ...any) int {
+	fmt.Fprintf(c.stderr, "violet %v: %v\n", c.command.name, fmt.Sprintf(format, a...))
+	return exitUsage
+}
+
+// Report that something went wrong, with the exit code it deserves.
+func (c *cli) fail(err error) int {
+	fmt.Fprintf(c.stderr, "violet %v: %v\n", c.command.name, err)
+	var notFound *inventory.NotFoundError
+	var ambiguous *inventory.AmbiguousError
+	if errors.As(err, &notFound) || errors.As(err, &ambiguous) {
+		return exitUsage
+	}
+	return exitFailed
+}
+
+// Find what each selector means.
+func (c *cli) selectAll(selectors []string) ([]inventory.Selection, error) {
+	environments, err := inventory.Load(c.ctx, c.runner)
+	if err != nil {
+		return nil, err
+	}
+	var selections []inventory.Selection
+	for _, selector := range selectors {
+		selection, err := inventory.Select(environments, selector)
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, selection)
+	}
+	return selections, nil
+}
+
+// Output formats for --format
+var formats = append([]string{"table"}, inventory.Formats...)
+
+// Add the --format flag to fs.
+func formatFlag(fs *flag.FlagSet) *string {
+	return fs.String("format", "table", "Print as "+strings.Join(formats, ", "))
+}
@@ -0,0 +1,245 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/braheezy/violet/internal/inventory"
+	"github.com/braheezy/violet/pkg/vagrant"
+	"github.com/braheezy/violet/pkg/vagrant/vagranttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMain(m *testing.M) {
+	// The ssh tests run Vagrant as a fake, which is this test binary
+	vagranttest.Main()
+	os.Exit(m.Run())
+}
+
+func newTestSimulator() *vagrant.Simulator {
+	return vagrant.NewSimulator(
+		vagrant.SimulatedMachine{ID: "1a2b3c4", Name: "web", Provider: "virtualbox", State: "running", Home: "/projects/site"},
+		vagrant.SimulatedMachine{ID: "5d6e7f8", Name: "db", Provider: "virtualbox", State: "poweroff", Home: "/projects/site"},
+		vagrant.SimulatedMachine{ID: "9a8b7c6", Name: "default", Provider: "libvirt", State: "not_created", Home: "/projects/tool"},
+	)
+}
+
+// Run the CLI with args, returning the exit code and what it printed.
+func runCLI(runner vagrant.Runner, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), runner, args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestList(t *testing.T) {
+	code, stdout, _ := runCLI(newTestSimulator(), "list")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, `ENVIRONMENT  MACHINE  ID       PROVIDER    STATE
+site         web      1a2b3c4  virtualbox  running
+site         db       5d6e7f8  virtualbox  poweroff
+tool         default  9a8b7c6  libvirt     not_created
+`, stdout)
+
+	code, stdout, _ = runCLI(newTestSimulator(), "list", "--format", "json")
+	assert.Equal(t, exitOK, code)
+	var environments []inventory.Environment
+	require.NoError(t, json.Unmarshal([]byte(stdout), &environments))
+	require.Len(t, environments, 2)
+	assert.Equal(t, "tool", environments[1].Name)
+	assert.Equal(t, "not_created", environments[1].Machines[0].State)
+
+	code, stdout, _ = runCLI(newTestSimulator(), "list", "--format", "yaml")
+	assert.Equal(t, exitOK, code)
+	environments = nil
+	require.NoError(t, yaml.Unmarshal([]byte(stdout), &environments))
+	require.Len(t, environments, 2)
+	assert.Equal(t, "/projects/site", environments[0].Home)
+
+	code, stdout, _ = runCLI(vagrant.NewSimulator(), "list", "--format=json")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "[]\n", stdout, "Verify no machines is still a list")
+
+	code, _, stderr := runCLI(newTestSimulator(), "list", "--format", "xml")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, `unknown format "xml"`)
+}
+
+func TestStatus(t *testing.T) {
+	sim := newTestSimulator()
+	// Changed behind the machine index's back
+	_, err := sim.Run(context.Background(), "", "up", "5d6e7f8", "--machine-readable")
+	require.NoError(t, err)
+
+	code, stdout, _ := runCLI(sim, "status", "site", "default")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, `ENVIRONMENT  MACHINE  ID       PROVIDER    STATE
+site         web      1a2b3c4  virtualbox  running
+site         db       5d6e7f8  virtualbox  running
+tool         default  9a8b7c6  libvirt     not_created
+`, stdout)
+
+	code, stdout, _ = runCLI(sim, "status", "site/db", "--format", "json")
+	assert.Equal(t, exitOK, code)
+	var machines []inventory.Machine
+	require.NoError(t, json.Unmarshal([]byte(stdout), &machines))
+	assert.Equal(t, []inventory.Machine{
+		{ID: "5d6e7f8", Name: "db", Provider: "virtualbox", State: "running", Home: "/projects/site"},
+	}, machines)
+
+	tests := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{"Verify a selector is needed", []string{"status"}, exitUsage},
+		{"Verify unknown selectors", []string{"status", "nope"}, exitUsage},
+		{"Verify unknown flags", []string{"status", "--nope", "site"}, exitUsage},
+	}
+	for _, test := range tests {
+		code, stdout, stderr := runCLI(sim, test.args...)
+		assert.Equal(t, test.code, code, test.name)
+		assert.Empty(t, stdout, test.name)
+		assert.NotEmpty(t, stderr, test.name)
+	}
+}
+
+func TestRunVagrant(t *testing.T) {
+	sim := newTestSimulator()
+	code, stdout, _ := runCLI(sim, "halt", "site")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "==> web: Attempting graceful shutdown of VM...\n==> db: Attempting graceful shutdown of VM...\n", stdout)
+	assert.Equal(t, "poweroff", sim.Machines()[0].State)
+
+	code, _, _ = runCLI(sim, "up", "1a2b")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "running", sim.Machines()[0].State)
+	assert.Equal(t, "poweroff", sim.Machines()[1].State, "Verify only the selected machine is started")
+
+	// The machine index still has it, but Vagrant doesn't
+	broken := vagrant.NewSimulator(vagrant.SimulatedMachine{ID: "1a2b3c4", Name: "web", Home: "/projects/site"})
+	code, _, stderr := runCLI(brokenRunner{broken}, "reload", "web")
+	assert.Equal(t, exitFailed, code)
+	assert.Contains(t, stderr, "reload on site/web failed")
+
+	code, _, _ = runCLI(sim, "up")
+	assert.Equal(t, exitUsage, code)
+}
+
+func TestRunVagrantError(t *testing.T) {
+	home := t.TempDir()
+	fake := vagranttest.New(t, vagranttest.Script{
+		MachineIndex: fmt.Sprintf(`{"version": 1, "machines": {
+			"1a2b3c4d5e": {"name": "web", "provider": "libvirt", "state": "shutoff", "vagrantfile_path": %q}
+		}}`, home),
+		Commands: []vagranttest.Command{
+			{
+				Args: []string{"up", "1a2b3c4", "--machine-readable"},
+				Output: `1695000000,web,ui,info,==> web: Starting domain.
+				1695000000,,error-exit,Vagrant::Errors::VagrantError,Call to virDomainCreateWithFlags failed: Requested operation is not valid: network 'default' is not active`,
+				ExitCode: 1,
+			},
+		},
+	})
+	client := &vagrant.VagrantClient{ExecPath: fake.ExecPath, Env: fake.Env()}
+
+	code, stdout, stderr := runCLI(client, "up", "web")
+	assert.Equal(t, exitFailed, code)
+	assert.Equal(t, "==> web: Starting domain.\n", stdout)
+	assert.Contains(t, stderr, "network 'default' is not active", "Verify Vagrant's reason is kept")
+}
+
+// A Runner whose machines can be listed but not found by Vagrant.
+type brokenRunner struct{ *vagrant.Simulator }
+
+func (r brokenRunner) Stream(ctx context.Context, dir string, args ...string) *vagrant.Stream {
+	return r.Simulator.Stream(ctx, "/nowhere", "status", "missing")
+}
+
+func TestSSH(t *testing.T) {
+	home := t.TempDir()
+	fake := vagranttest.New(t, vagranttest.Script{
+		MachineIndex: fmt.Sprintf(`{"version": 1, "machines": {
+			"1a2b3c4d5e": {"name": "web", "provider": "virtualbox", "state": "running", "vagrantfile_path": %q}
+		}}`, home),
+		Commands: []vagranttest.Command{
+			{Args: []string{"ssh", "1a2b3c4", "-c", "uptime"}, Output: "up 3 days"},
+			{Args: []string{"ssh", "1a2b3c4", "-c", "false"}, ExitCode: 7},
+		},
+	})
+	client := &vagrant.VagrantClient{ExecPath: fake.ExecPath, Env: fake.Env()}
+
+	code, stdout, _ := runCLI(client, "ssh", "web", "--", "-c", "uptime")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "up 3 days\n", stdout)
+
+	code, _, _ = runCLI(client, "ssh", "web", "--", "-c", "false")
+	assert.Equal(t, 7, code, "Verify the exit code of the remote command is kept")
+
+	code, _, stderr := runCLI(client, "ssh", filepath.Base(home))
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, "is an environment")
+	code, _, _ = runCLI(newTestSimulator(), "ssh", "web")
+	assert.Equal(t, exitFailed, code, "Verify ssh needs the real Vagrant")
+}
+
+func TestAnsible(t *testing.T) {
+	code, stdout, _ := runCLI(newTestSimulator(), "ansible")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, `[site]
+site-web ansible_host=127.0.0.1 ansible_port=2222 ansible_user=vagrant ansible_ssh_private_key_file=/projects/site/.vagrant/machines/web/virtualbox/private_key
+`, stdout, "Verify only running machines are included")
+
+	code, stdout, _ = runCLI(newTestSimulator(), "ansible", "--format", "yaml")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, "    site:\n      hosts:\n        site-web:\n")
+
+	code, stdout, _ = runCLI(newTestSimulator(), "list", "--ssh", "--format", "json")
+	assert.Equal(t, exitOK, code)
+	var environments []inventory.Environment
+	require.NoError(t, json.Unmarshal([]byte(stdout), &environments))
+	require.NotNil(t, environments[0].Machines[0].SSH)
+	assert.Equal(t, 2222, environments[0].Machines[0].SSH.Port)
+
+	code, _, _ = runCLI(newTestSimulator(), "ansible", "--format", "json")
+	assert.Equal(t, exitUsage, code)
+}
+
+func TestSSHConfig(t *testing.T) {
+	code, stdout, _ := runCLI(newTestSimulator(), "ssh-config")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, `Host site-web
+  HostName 127.0.0.1
+  User vagrant
+  Port 2222
+  IdentityFile /projects/site/.vagrant/machines/web/virtualbox/private_key
+  IdentitiesOnly yes
+`, stdout)
+
+	path := filepath.Join(t.TempDir(), "violet.conf")
+	code, stdout, _ = runCLI(newTestSimulator(), "ssh-config", "--write", "--file", path)
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "Updated "+path+"\n", stdout)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "Host site-web\n")
+
+	code, _, _ = runCLI(newTestSimulator(), "ssh-config", "--file", path)
+	assert.Equal(t, exitUsage, code, "Verify --file needs --write")
+}
+
+func TestUsage(t *testing.T) {
+	code, stdout, _ := runCLI(newTestSimulator(), "help")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, "violet list")
+
+	code, _, stderr := runCLI(newTestSimulator(), "nope")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, `unknown command "nope"`)
+}
@@ -0,0 +1,290 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/braheezy/violet/internal/inventory"
+	"github.com/braheezy/violet/pkg/vagrant"
+)
+
+// violet list
+func runList(c *cli, args []string) int {
+	fs := c.flags()
+	format := formatFlag(fs)
+	withSSH := fs.Bool("ssh", false, "Include how to connect to running machines, which asks Vagrant about each one")
+	positional, err := parse(fs, args)
+	if err != nil {
+		return exitUsage
+	}
+	if len(positional) > 0 {
+		return c.usageError("takes no arguments")
+	}
+	if !slices.Contains(formats, *format) {
+		return c.usageError("unknown format %q", *format)
+	}
+
+	environments, err := inventory.Load(c.ctx, c.runner)
+	if err != nil {
+		return c.fail(err)
+	}
+	if *withSSH {
+		if err := inventory.LoadSSH(c.ctx, c.runner, environments); err != nil {
+			return c.fail(err)
+		}
+	}
+	if *format != "table" {
+		if environments == nil {
+			environments = []inventory.Environment{}
+		}
+		return c.print(*format, environments)
+	}
+
+	var machines []inventory.Machine
+	for _, env := range environments {
+		machines = append(machines, env.Machines...)
+	}
+	c.printTable(environments, machines)
+	return exitOK
+}
+
+// violet status
+func runStatus(c *cli, args []string) int {
+	fs := c.flags()
+	format := formatFlag(fs)
+	selectors, err := parse(fs, args)
+	if err != nil {
+		return exitUsage
+	}
+	if len(selectors) == 0 {
+		return c.usageError("which environments or machines? See `violet list`")
+	}
+	if !slices.Contains(formats, *format) {
+		return c.usageError("unknown format %q", *format)
+	}
+	selections, err := c.selectAll(selectors)
+	if err != nil {
+		return c.fail(err)
+	}
+
+	// The index may be stale, so ask Vagrant
+	machines := []inventory.Machine{}
+	var environments []inventory.Environment
+	for _, selection := range selections {
+		env := selection.Environment
+		var output string
+		if selection.Machine != nil {
+			output, err = c.runner.Status(c.ctx, "", selection.Machine.ID)
+		} else {
+			output, err = c.runner.Status(c.ctx, env.Home, "")
+		}
+		if err != nil {
+			return c.fail(err)
+		}
+		for _, status := range vagrant.ParseStatus(output) {
+			machine := inventory.Machine{Name: status.Name, Provider: status.Provider, State: status.State, Home: env.Home}
+			// Status doesn't say what the IDs are
+			for _, known := range env.Machines {
+				if known.Name == status.Name {
+					machine.ID = known.ID
+				}
+			}
+			machines = append(machines, machine)
+		}
+		environments = append(environments, env)
+	}
+
+	if *format != "table" {
+		return c.print(*format, machines)
+	}
+	c.printTable(environments, machines)
+	return exitOK
+}
+
+// violet up, halt or reload
+func runVagrant(vagrantCommand string) func(c *cli, args []string) int {
+	return func(c *cli, args []string) int {
+		selectors, err := parse(c.flags(), args)
+		if err != nil {
+			return exitUsage
+		}
+		if len(selectors) == 0 {
+			return c.usageError("which environments or machines? See `violet list`")
+		}
+		selections, err := c.selectAll(selectors)
+		if err != nil {
+			return c.fail(err)
+		}
+
+		code := exitOK
+		for _, selection := range selections {
+			var stream *vagrant.Stream
+			if selection.Machine != nil {
+				stream = c.runner.Stream(c.ctx, "", vagrantCommand, selection.Machine.ID, "--machine-readable")
+			} else {
+				stream = c.runner.Stream(c.ctx, selection.Environment.Home, vagrantCommand, "--machine-readable")
+			}
+			if err := c.printStream(stream); err != nil {
+				fmt.Fprintf(c.stderr, "violet %v: %v on %v failed: %v\n", vagrantCommand, vagrantCommand, selection.Target(), err)
+				code = exitFailed
+			}
+			if c.ctx.Err() != nil {
+				// Interrupted, don't start anything else
+				return exitFailed
+			}
+		}
+		return code
+	}
+}
+
+// Runners that can hand the terminal over to Vagrant, like VagrantClient.
+type interactiveRunner interface {
+	Command(dir string, args ...string) *exec.Cmd
+}
+
+// violet ssh
+func runSSH(c *cli, args []string) int {
+	positional, err := parse(c.flags(), args)
+	if err != nil {
+		return exitUsage
+	}
+	if len(positional) == 0 {
+		return c.usageError("which machine? See `violet list`")
+	}
+	runner, ok := c.runner.(interactiveRunner)
+	if !ok {
+		return c.fail(errors.New("needs Vagrant to be installed"))
+	}
+	selections, err := c.selectAll(positional[:1])
+	if err != nil {
+		return c.fail(err)
+	}
+	machine := selections[0].Machine
+	if machine == nil {
+		return c.usageError("%v is an environment, pick one of its machines", selections[0].Target())
+	}
+
+	cmd := runner.Command("", append([]string{"ssh", machine.ID}, positional[1:]...)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = c.stdout
+	cmd.Stderr = c.stderr
+	if err := cmd.Run(); err != nil {
+		// Pass on the exit code of whatever ran over SSH
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode()
+		}
+		return c.fail(err)
+	}
+	return exitOK
+}
+
+// violet ansible
+func runAnsible(c *cli, args []string) int {
+	fs := c.flags()
+	format := fs.String("format", "ini", "Write the inventory as "+strings.Join(inventory.AnsibleFormats, " or "))
+	positional, err := parse(fs, args)
+	if err != nil {
+		return exitUsage
+	}
+	if len(positional) > 0 {
+		return c.usageError("takes no arguments")
+	}
+	if !slices.Contains(inventory.AnsibleFormats, *format) {
+		return c.usageError("unknown format %q", *format)
+	}
+
+	environments, err := inventory.Load(c.ctx, c.runner)
+	if err != nil {
+		return c.fail(err)
+	}
+	if err := inventory.LoadSSH(c.ctx, c.runner, environments); err != nil {
+		return c.fail(err)
+	}
+	if err := inventory.WriteAnsible(c.stdout, *format, environments); err != nil {
+		return c.fail(err)
+	}
+	return exitOK
+}
+
+// violet ssh-config
+func runSSHConfig(c *cli, args []string) int {
+	fs := c.flags()
+	write := fs.Bool("write", false, "Update violet's block in the SSH config instead of printing it")
+	path := fs.String("file", "", "The SSH config to update, ~/.ssh/config by default. Any other file can be Included from it")
+	positional, err := parse(fs, args)
+	if err != nil {
+		return exitUsage
+	}
+	if len(positional) > 0 {
+		return c.usageError("takes no arguments")
+	}
+	if *path != "" && !*write {
+		return c.usageError("--file is only used with --write")
+	}
+
+	environments, err := inventory.Load(c.ctx, c.runner)
+	if err != nil {
+		return c.fail(err)
+	}
+	if err := inventory.LoadSSH(c.ctx, c.runner, environments); err != nil {
+		return c.fail(err)
+	}
+	if !*write {
+		if err := inventory.WriteSSHConfig(c.stdout, environments); err != nil {
+			return c.fail(err)
+		}
+		return exitOK
+	}
+
+	if *path == "" {
+		if *path, err = inventory.DefaultSSHConfigPath(); err != nil {
+			return c.fail(err)
+		}
+	}
+	if err := inventory.UpdateSSHConfig(*path, environments); err != nil {
+		return c.fail(err)
+	}
+	fmt.Fprintln(c.stdout, "Updated", *path)
+	return exitOK
+}
+
+// Print the output of stream for people as it comes, returning how the command ended.
+func (c *cli) printStream(stream *vagrant.Stream) error {
+	for line := range stream.Lines {
+		switch line.Type {
+		case "error-exit":
+			// Explained by the error
+		case "", "ui":
+			fmt.Fprintln(c.stdout, line.Text())
+		}
+	}
+	return stream.Wait()
+}
+
+// Print machines as a table, labelled with the environment they're in.
+func (c *cli) printTable(environments []inventory.Environment, machines []inventory.Machine) {
+	names := make(map[string]string)
+	for _, env := range environments {
+		names[env.Home] = env.Name
+	}
+	w := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ENVIRONMENT\tMACHINE\tID\tPROVIDER\tSTATE")
+	for _, machine := range machines {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n", names[machine.Home], machine.Name, machine.ID, machine.Provider, machine.State)
+	}
+	w.Flush()
+}
+
+// Print v for scripts, as JSON or YAML.
+func (c *cli) print(format string, v any) int {
+	if err := inventory.Write(c.stdout, format, v); err != nil {
+		return c.fail(err)
+	}
+	return exitOK
+}
@@ -0,0 +1,232 @@
+// Package config is how violet is set up, from a TOML file in the user's config
+// directory e.g. ~/.config/violet/config.toml. Everything has a default, so the
+// file and every setting in it are optional.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/braheezy/violet/pkg/vagrant"
+	tint "github.com/lrstanley/bubbletint"
+)
+
+// Config is every setting violet has.
+type Config struct {
+	// Theme is the ID of a bubbletint theme e.g. dracula. Empty picks one that suits the terminal's background.
+	Theme string `toml:"theme"`
+	// PageSize is how many environment tabs are shown at once.
+	PageSize int `toml:"page_size"`
+	// RefreshInterval is how often machine states are refreshed, zero to never.
+	RefreshInterval time.Duration `toml:"refresh_interval"`
+	// Parallelism is how many commands may run at once.
+	Parallelism int `toml:"parallelism"`
+	// MachineCommands are the commands shown for machines, in order.
+	MachineCommands []string `toml:"machine_commands"`
+	// EnvCommands are the commands shown for whole environments, in order.
+	EnvCommands []string `toml:"env_commands"`
+	// DefaultFocus is what's selected in a new environment tab, "environment" or "machine".
+	DefaultFocus string `toml:"default_focus"`
+	// Keys remaps actions to other keys.
+	Keys    Keys    `toml:"keys"`
+	Vagrant Vagrant `toml:"vagrant"`
+}
+
+// Vagrant is how Vagrant is run.
+type Vagrant struct {
+	// Path to the vagrant executable. Empty finds it on the PATH.
+	Path string `toml:"path"`
+	// Env is extra environment variables for Vagrant e.g. VAGRANT_HOME.
+	Env map[string]string `toml:"env"`
+	// Timeout is how long violet waits for Vagrant to answer a question like status,
+	// zero to wait forever. Commands like up that stream their output are never timed out.
+	Timeout time.Duration `toml:"timeout"`
+}
+
+// Every command violet knows how to run.
+var (
+	machineCommands = []string{"up", "halt", "ssh", "reload", "provision", "suspend", "resume", "destroy"}
+	envCommands     = []string{"up", "halt", "reload", "provision", "suspend", "resume", "destroy"}
+	focuses         = []string{"environment", "machine"}
+)
+
+// Default is how violet is set up without a config file.
+func Default() Config {
+	return Config{
+		PageSize:        5,
+		RefreshInterval: 30 * time.Second,
+		Parallelism:     4,
+		MachineCommands: slices.Clone(machineCommands),
+		EnvCommands:     slices.Clone(envCommands),
+		DefaultFocus:    "environment",
+		Keys:            DefaultKeys(),
+		Vagrant:         Vagrant{Timeout: vagrant.DefaultTimeout},
+	}
+}
+
+// Paths are where the config file is looked for, in order: VIOLET_CONFIG if it's set,
+// or violet/config.toml in $XDG_CONFIG_HOME (~/.config by default) then each of $XDG_CONFIG_DIRS.
+func Paths() []string {
+	if path := os.Getenv("VIOLET_CONFIG"); path != "" {
+		return []string{path}
+	}
+	var dirs []string
+	if dir := os.Getenv("XDG_CONFIG_HOME"); filepath.IsAbs(dir) {
+		dirs = append(dirs, dir)
+	} else if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".config"))
+	}
+	systemDirs := os.Getenv("XDG_CONFIG_DIRS")
+	if systemDirs == "" {
+		systemDirs = "/etc/xdg"
+	}
+	for _, dir := range filepath.SplitList(systemDirs) {
+		// Relative paths are invalid and ignored, says the spec
+		if filepath.IsAbs(dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	paths := make([]string, len(dirs))
+	for i, dir := range dirs {
+		paths[i] = filepath.Join(dir, "violet", "config.toml")
+	}
+	return paths
+}
+
+// Load the first config file in Paths, or the defaults if there isn't one.
+func Load() (Config, error) {
+	paths := Paths()
+	for _, path := range paths {
+		config, err := LoadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		return config, err
+	}
+	if os.Getenv("VIOLET_CONFIG") != "" {
+		// Asked for by name, so it should be there
+		return Config{}, fmt.Errorf("%v: no such file", paths[0])
+	}
+	return Default(), nil
+}
+
+// LoadFile loads the config file at path, on top of the defaults, and validates it.
+func LoadFile(path string) (Config, error) {
+	config := Default()
+	metadata, err := toml.DecodeFile(path, &config)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Config{}, err
+	} else if err != nil {
+		return Config{}, fmt.Errorf("%v: %w", path, err)
+	}
+
+	var problems []error
+	for _, key := range metadata.Undecoded() {
+		problems = append(problems, fmt.Errorf("unknown setting %q", key.String()))
+	}
+	if err := config.Validate(); err != nil {
+		problems = append(problems, err)
+	}
+	if err := errors.Join(problems...); err != nil {
+		return Config{}, fmt.Errorf("%v: %w", path, err)
+	}
+	return config, nil
+}
+
+// Validate returns every problem with the settings, or nil if there aren't any.
+func (c Config) Validate() error {
+	var problems []error
+	if c.Theme != "" && !slices.Contains(ThemeIDs(), c.Theme) {
+		problems = append(problems, fmt.Errorf("theme %q doesn't exist, see https://github.com/lrstanley/bubbletint/blob/master/DEFAULT_TINTS.md", c.Theme))
+	}
+	if c.PageSize < 1 {
+		problems = append(problems, fmt.Errorf("page_size must be at least 1, not %v", c.PageSize))
+	}
+	if c.RefreshInterval < 0 {
+		problems = append(problems, fmt.Errorf("refresh_interval can't be negative, use 0 to never refresh"))
+	}
+	if c.Parallelism < 1 {
+		problems = append(problems, fmt.Errorf("parallelism must be at least 1, not %v", c.Parallelism))
+	}
+	problems = append(problems, validateCommands("machine_commands", c.MachineCommands, machineCommands)...)
+	problems = append(problems, validateCommands("env_commands", c.EnvCommands, envCommands)...)
+	if !slices.Contains(focuses, c.DefaultFocus) {
+		problems = append(problems, fmt.Errorf("default_focus must be one of %v, not %q", strings.Join(focuses, ", "), c.DefaultFocus))
+	}
+	problems = append(problems, c.Keys.validate()...)
+	if c.Vagrant.Path != "" {
+		if _, err := exec.LookPath(c.Vagrant.Path); err != nil {
+			problems = append(problems, fmt.Errorf("vagrant.path: %w", err))
+		}
+	}
+	if c.Vagrant.Timeout < 0 {
+		problems = append(problems, fmt.Errorf("vagrant.timeout can't be negative, use 0 to never time out"))
+	}
+	for name := range c.Vagrant.Env {
+		if name == "" || strings.Contains(name, "=") {
+			problems = append(problems, fmt.Errorf("vagrant.env: %q isn't a valid variable name", name))
+		}
+	}
+	return errors.Join(problems...)
+}
+
+func validateCommands(setting string, commands []string, known []string) []error {
+	var problems []error
+	if len(commands) == 0 {
+		problems = append(problems, fmt.Errorf("%v can't be empty", setting))
+	}
+	for i, command := range commands {
+		if !slices.Contains(known, command) {
+			problems = append(problems, fmt.Errorf("%v: unknown command %q, pick from %v", setting, command, strings.Join(known, ", ")))
+		} else if slices.Index(commands, command) < i {
+			problems = append(problems, fmt.Errorf("%v: %q is listed more than once", setting, command))
+		}
+	}
+	return problems
+}
+
+// ThemeIDs are the themes that can be picked.
+func ThemeIDs() []string {
+	var ids []string
+	for _, t := range tint.DefaultTints() {
+		ids = append(ids, t.ID())
+	}
+	return ids
+}
+
+// NewVagrantClient returns a client that runs Vagrant the way the config says.
+func (c Config) NewVagrantClient() (*vagrant.VagrantClient, error) {
+	var client *vagrant.VagrantClient
+	if c.Vagrant.Path == "" {
+		var err error
+		if client, err = vagrant.NewVagrantClient(); err != nil {
+			return nil, err
+		}
+	} else {
+		client = &vagrant.VagrantClient{
+			ExecPath:    c.Vagrant.Path,
+			Env:         os.Environ(),
+			GracePeriod: vagrant.DefaultGracePeriod,
+		}
+	}
+	client.Timeout = c.Vagrant.Timeout
+	// Sorted so Vagrant always sees the same environment
+	names := make([]string, 0, len(c.Vagrant.Env))
+	for name := range c.Vagrant.Env {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		client.Env = append(client.Env, name+"="+c.Vagrant.Env[name])
+	}
+	return client, nil
+}
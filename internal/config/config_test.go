@@ -0,0 +1,157 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Write a config file with contents and return its path.
+func writeConfig(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o644))
+	return path
+}
+
+func TestLoadFile(t *testing.T) {
+	path := writeConfig(t, `
+theme = "dracula"
+page_size = 8
+refresh_interval = "1m"
+machine_commands = ["ssh", "up", "halt"]
+default_focus = "machine"
+
+[keys]
+quit = ["ctrl+q"]
+box_close = ["esc", "q"]
+
+[vagrant]
+env = { VAGRANT_HOME = "/srv/vagrant.d" }
+timeout = "5m"
+`)
+	config, err := LoadFile(path)
+	require.NoError(t, err)
+
+	expected := Default()
+	expected.Theme = "dracula"
+	expected.PageSize = 8
+	expected.RefreshInterval = time.Minute
+	expected.MachineCommands = []string{"ssh", "up", "halt"}
+	expected.DefaultFocus = "machine"
+	expected.Keys["quit"] = []string{"ctrl+q"}
+	expected.Vagrant.Env = map[string]string{"VAGRANT_HOME": "/srv/vagrant.d"}
+	expected.Vagrant.Timeout = 5 * time.Minute
+	assert.Equal(t, expected, config, "Verify anything not set keeps its default")
+
+	config, err = LoadFile(writeConfig(t, `refresh_interval = "0s"`))
+	require.NoError(t, err)
+	assert.Zero(t, config.RefreshInterval, "Verify refreshing can be turned off")
+}
+
+func TestLoadFileErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		problems []string
+	}{
+		{"Verify TOML errors", `page_size = `, []string{"line 1"}},
+		{"Verify unknown settings", "page_size = 3\npagesize = 4", []string{`unknown setting "pagesize"`}},
+		{"Verify unknown themes", `theme = "nope"`, []string{`theme "nope" doesn't exist`}},
+		{"Verify numbers are checked", "page_size = 0\nparallelism = -1", []string{"page_size must be at least 1", "parallelism must be at least 1"}},
+		{"Verify negative intervals", `refresh_interval = "-1s"`, []string{"refresh_interval can't be negative"}},
+		{
+			"Verify commands are checked",
+			`machine_commands = ["up", "dance", "up"]` + "\n" + `env_commands = ["ssh"]`,
+			[]string{`machine_commands: unknown command "dance"`, `machine_commands: "up" is listed more than once`, `env_commands: unknown command "ssh"`},
+		},
+		{"Verify commands can't be empty", `env_commands = []`, []string{"env_commands can't be empty"}},
+		{"Verify the focus is checked", `default_focus = "box"`, []string{"default_focus must be one of environment, machine"}},
+		{
+			"Verify keys are checked",
+			"[keys]\nquit = [\"ctrl+Q\"]\nfly = [\"f\"]\nhelp = []",
+			[]string{`keys.quit: unknown key "ctrl+Q"`, `keys: unknown action "fly"`, "keys.help can't be empty"},
+		},
+		{
+			"Verify keys can't do two things on one screen",
+			"[keys]\nquit = [\"r\"]\nsnapshot_new = [\"j\"]",
+			[]string{`keys: "r" does both refresh and quit on the main screen`, `keys: "j" does both down and snapshot_new on the snapshot screen`},
+		},
+		{"Verify Vagrant is checked", "[vagrant]\npath = \"/nowhere/vagrant\"\ntimeout = \"-1m\"", []string{"vagrant.path", "vagrant.timeout can't be negative"}},
+	}
+	for _, test := range tests {
+		path := writeConfig(t, test.contents)
+		_, err := LoadFile(path)
+		require.Error(t, err, test.name)
+		assert.Contains(t, err.Error(), path, test.name)
+		for _, problem := range test.problems {
+			assert.Contains(t, err.Error(), problem, test.name)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("VIOLET_CONFIG", "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_CONFIG_DIRS", filepath.Join(home, "etc"))
+
+	config, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, Default(), config, "Verify there doesn't have to be a config file")
+
+	system := filepath.Join(home, "etc", "violet", "config.toml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(system), 0o755))
+	require.NoError(t, os.WriteFile(system, []byte("page_size = 3"), 0o644))
+	config, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, 3, config.PageSize, "Verify XDG_CONFIG_DIRS is searched")
+
+	user := filepath.Join(home, ".config", "violet", "config.toml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(user), 0o755))
+	require.NoError(t, os.WriteFile(user, []byte("page_size = 4"), 0o644))
+	config, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, 4, config.PageSize, "Verify the user's config comes first")
+
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "xdg"))
+	assert.Equal(t, filepath.Join(home, "xdg", "violet", "config.toml"), Paths()[0])
+
+	t.Setenv("VIOLET_CONFIG", filepath.Join(home, "missing.toml"))
+	_, err = Load()
+	assert.ErrorContains(t, err, "missing.toml: no such file", "Verify a config asked for by name has to exist")
+}
+
+func TestNewVagrantClient(t *testing.T) {
+	vagrant := filepath.Join(t.TempDir(), "vagrant")
+	require.NoError(t, os.WriteFile(vagrant, []byte("#!/bin/sh\n"), 0o755))
+	config := Default()
+	config.Vagrant.Path = vagrant
+	config.Vagrant.Env = map[string]string{"VAGRANT_HOME": "/srv/vagrant.d", "VAGRANT_LOG": "info"}
+	config.Vagrant.Timeout = 0
+	require.NoError(t, config.Validate())
+
+	client, err := config.NewVagrantClient()
+	require.NoError(t, err)
+	assert.Equal(t, vagrant, client.ExecPath)
+	assert.Zero(t, client.Timeout, "Verify the timeout can be turned off")
+	assert.Equal(t, []string{"VAGRANT_HOME=/srv/vagrant.d", "VAGRANT_LOG=info"}, client.Env[len(client.Env)-2:])
+	home, err := client.VagrantHome()
+	require.NoError(t, err)
+	assert.Equal(t, "/srv/vagrant.d", home, "Verify the config wins over the environment")
+}
+
+func TestKeys(t *testing.T) {
+	assert.NoError(t, Default().Validate(), "Verify the default keys don't clash")
+
+	config := Default()
+	// Different screens, so no clash
+	config.Keys["box_prune"] = []string{"e"}
+	config.Keys["help"] = []string{"alt+h", "f1"}
+	assert.NoError(t, config.Validate())
+}
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Keys maps actions e.g. "quit" to the keys that do them, named the way Bubble Tea names
+// them e.g. "q", "ctrl+c", "shift+tab", "pgup" or " " for space. Actions the config file
+// doesn't mention keep their default keys.
+type Keys map[string][]string
+
+// Every action that has keys, with its default keys.
+var keyActions = []struct {
+	name string
+	keys []string
+}{
+	{"up", []string{"up", "k"}},
+	{"down", []string{"down", "j"}},
+	{"left", []string{"left", "h"}},
+	{"right", []string{"right", "l"}},
+	{"next_tab", []string{"tab"}},
+	{"prev_tab", []string{"shift+tab"}},
+	{"run", []string{"enter"}},
+	{"toggle_focus", []string{" "}},
+	{"cancel", []string{"c"}},
+	{"snapshots", []string{"s"}},
+	{"details", []string{"i"}},
+	{"boxes", []string{"b"}},
+	{"refresh", []string{"r"}},
+	{"export", []string{"e"}},
+	{"ansible", []string{"a"}},
+	{"ssh_config", []string{"w"}},
+	{"scroll_up", []string{"pgup"}},
+	{"scroll_down", []string{"pgdown"}},
+	{"filter", []string{"/"}},
+	{"table", []string{"v"}},
+	{"mark", []string{"x"}},
+	{"clear_marks", []string{"X"}},
+	{"sort", []string{"o"}},
+	{"sort_reverse", []string{"O"}},
+	{"themes", []string{"t"}},
+	{"help", []string{"?"}},
+	{"quit", []string{"q", "esc", "ctrl+c"}},
+	{"box_update", []string{"u"}},
+	{"box_remove", []string{"d"}},
+	{"box_prune", []string{"p"}},
+	{"box_close", []string{"esc", "q"}},
+	{"snapshot_new", []string{"n"}},
+	{"snapshot_restore", []string{"r", "enter"}},
+	{"snapshot_delete", []string{"d"}},
+	{"snapshot_close", []string{"esc", "q"}},
+	{"details_refresh", []string{"r"}},
+	{"details_open", []string{"o"}},
+	{"details_copy", []string{"y"}},
+	{"details_close", []string{"esc", "q"}},
+	{"theme_apply", []string{"enter"}},
+	{"theme_close", []string{"esc", "q"}},
+	{"filter_apply", []string{"enter"}},
+	{"filter_clear", []string{"esc"}},
+}
+
+// The actions available on each screen. Actions on the same screen can't share a key.
+var keyScreens = []struct {
+	name    string
+	actions []string
+}{
+	{"main", []string{
+		"up", "down", "left", "right", "next_tab", "prev_tab", "run", "toggle_focus", "cancel", "snapshots", "details",
+		"boxes", "refresh", "export", "ansible", "ssh_config", "scroll_up", "scroll_down", "filter", "table", "sort", "sort_reverse", "mark", "clear_marks", "themes", "help", "quit",
+	}},
+	// The key that opens a screen also closes it
+	{"box", []string{"up", "down", "box_update", "box_remove", "box_prune", "refresh", "cancel", "scroll_up", "scroll_down", "box_close", "boxes"}},
+	{"snapshot", []string{"up", "down", "snapshot_new", "snapshot_restore", "snapshot_delete", "snapshot_close", "snapshots"}},
+	{"details", []string{"up", "down", "details_refresh", "details_open", "details_copy", "details_close", "details"}},
+	{"theme", []string{"up", "down", "theme_apply", "theme_close", "themes"}},
+	// Anything else is typed into the filter
+	{"filter", []string{"filter_apply", "filter_clear", "next_tab", "prev_tab"}},
+}
+
+// Every key name Bubble Tea has that isn't a single character e.g. "enter" or "ctrl+c".
+var keyNames = func() []string {
+	var names []string
+	for t := tea.KeyType(-1000); t < 1000; t++ {
+		if name := t.String(); t != tea.KeyRunes && utf8.RuneCountInString(name) > 1 {
+			names = append(names, name)
+		}
+	}
+	return names
+}()
+
+// DefaultKeys are the keys for every action without a config file.
+func DefaultKeys() Keys {
+	keys := make(Keys, len(keyActions))
+	for _, action := range keyActions {
+		keys[action.name] = slices.Clone(action.keys)
+	}
+	return keys
+}
+
+// KeyActions are the names of every action that has keys.
+func KeyActions() []string {
+	names := make([]string, len(keyActions))
+	for i, action := range keyActions {
+		names[i] = action.name
+	}
+	return names
+}
+
+// Return every problem with the keys: unknown actions or keys, and keys shared by actions on the same screen.
+func (k Keys) validate() []error {
+	var problems []error
+	for _, action := range slices.Sorted(maps.Keys(k)) {
+		if !slices.Contains(KeyActions(), action) {
+			problems = append(problems, fmt.Errorf("keys: unknown action %q, pick from %v", action, strings.Join(KeyActions(), ", ")))
+			continue
+		}
+		if len(k[action]) == 0 {
+			problems = append(problems, fmt.Errorf("keys.%v can't be empty", action))
+		}
+		for _, key := range k[action] {
+			if !validKey(key) {
+				problems = append(problems, fmt.Errorf("keys.%v: unknown key %q", action, key))
+			}
+		}
+	}
+
+	// Actions on several screens would clash on each of them, so only say so once
+	reported := make(map[[2]string]bool)
+	for _, screen := range keyScreens {
+		owners := make(map[string]string)
+		for _, action := range screen.actions {
+			for _, key := range k[action] {
+				owner, taken := owners[key]
+				if !taken {
+					owners[key] = action
+					continue
+				}
+				if owner == action || reported[[2]string{owner, action}] {
+					continue
+				}
+				reported[[2]string{owner, action}] = true
+				problems = append(problems, fmt.Errorf("keys: %q does both %v and %v on the %v screen", key, owner, action, screen.name))
+			}
+		}
+	}
+	return problems
+}
+
+// Whether Bubble Tea can report key e.g. "x", "alt+x", "ctrl+x" or "pgup".
+func validKey(key string) bool {
+	key = strings.TrimPrefix(key, "alt+")
+	return utf8.RuneCountInString(key) == 1 || slices.Contains(keyNames, key)
+}
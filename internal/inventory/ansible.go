@@ -0,0 +1,197 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/braheezy/violet/pkg/vagrant"
+	"gopkg.in/yaml.v3"
+)
+
+// AnsibleFormats are the Ansible inventory formats WriteAnsible supports.
+var AnsibleFormats = []string{"ini", "yaml"}
+
+var (
+	// Characters that can't be in a host alias
+	aliasUnsafe = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+	// Characters Ansible warns about in group names
+	groupUnsafe = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+)
+
+// HostAlias is what machine is called outside of Vagrant e.g. app-web. Different machines
+// can have the same alias e.g. bar-baz in foo and baz in foo-bar, see HostAliases.
+func HostAlias(env Environment, machine Machine) string {
+	return aliasUnsafe.ReplaceAllString(env.Name, "-") + "-" + aliasUnsafe.ReplaceAllString(machine.Name, "-")
+}
+
+// HostAliases returns the HostAlias of every machine in environments, by environment and
+// then machine. Machines that would share an alias are told apart with a number, e.g.
+// foo-bar-baz and foo-bar-baz-2, the first one keeping the alias as it is.
+func HostAliases(environments []Environment) [][]string {
+	taken := make(map[string]bool)
+	for _, env := range environments {
+		for _, machine := range env.Machines {
+			taken[HostAlias(env, machine)] = true
+		}
+	}
+	used := make(map[string]bool)
+	aliases := make([][]string, len(environments))
+	for i, env := range environments {
+		for _, machine := range env.Machines {
+			alias := HostAlias(env, machine)
+			if used[alias] {
+				n := 2
+				for taken[fmt.Sprintf("%v-%v", alias, n)] {
+					n++
+				}
+				alias = fmt.Sprintf("%v-%v", alias, n)
+				taken[alias] = true
+			}
+			used[alias] = true
+			aliases[i] = append(aliases[i], alias)
+		}
+	}
+	return aliases
+}
+
+// The Ansible group for the machines in env.
+func ansibleGroup(env Environment) string {
+	group := groupUnsafe.ReplaceAllString(env.Name, "_")
+	if group == "" || (group[0] >= '0' && group[0] <= '9') {
+		group = "env_" + group
+	}
+	return group
+}
+
+// LoadSSH asks Vagrant how to connect to every running machine in environments and sets their SSH.
+// Machines Vagrant can't connect to are left without it.
+func LoadSSH(ctx context.Context, runner vagrant.Runner, environments []Environment) error {
+	for i := range environments {
+		for j := range environments[i].Machines {
+			machine := &environments[i].Machines[j]
+			if machine.State != "running" || machine.ID == "" {
+				continue
+			}
+			config, err := vagrant.GetSSHConfig(ctx, runner, machine.ID)
+			var commandErr *vagrant.CommandError
+			if errors.As(err, &commandErr) {
+				// e.g. it's still booting
+				continue
+			} else if err != nil {
+				return err
+			}
+			port, _ := strconv.Atoi(config.Port)
+			machine.SSH = &SSH{
+				Host:         config.HostName,
+				Port:         port,
+				User:         config.User,
+				IdentityFile: config.IdentityFile,
+				Options:      config.Options,
+			}
+		}
+	}
+	return nil
+}
+
+// The Ansible variables to connect to machine.
+func ansibleVars(ssh *SSH) [][2]string {
+	vars := [][2]string{
+		{"ansible_host", ssh.Host},
+		{"ansible_port", strconv.Itoa(ssh.Port)},
+		{"ansible_user", ssh.User},
+	}
+	if ssh.IdentityFile != "" {
+		vars = append(vars, [2]string{"ansible_ssh_private_key_file", ssh.IdentityFile})
+	}
+	return vars
+}
+
+// WriteAnsible writes an Ansible inventory to w as format, one of AnsibleFormats. Each environment
+// is a group of its machines, named with HostAliases. Only machines with SSH are included, see LoadSSH.
+func WriteAnsible(w io.Writer, format string, environments []Environment) error {
+	switch format {
+	case "ini":
+		return writeAnsibleINI(w, environments)
+	case "yaml":
+		return writeAnsibleYAML(w, environments)
+	}
+	return fmt.Errorf("unknown format %q", format)
+}
+
+func writeAnsibleINI(w io.Writer, environments []Environment) error {
+	aliases := HostAliases(environments)
+	var b strings.Builder
+	for i, env := range environments {
+		var hosts []string
+		for j, machine := range env.Machines {
+			if machine.SSH == nil {
+				continue
+			}
+			host := aliases[i][j]
+			for _, v := range ansibleVars(machine.SSH) {
+				value := v[1]
+				if strings.ContainsAny(value, " \t\"'") {
+					value = strconv.Quote(value)
+				}
+				host += " " + v[0] + "=" + value
+			}
+			hosts = append(hosts, host)
+		}
+		if len(hosts) == 0 {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "[%v]\n%v\n", ansibleGroup(env), strings.Join(hosts, "\n"))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeAnsibleYAML(w io.Writer, environments []Environment) error {
+	// Nodes rather than maps, to keep everything in the order violet shows it
+	mapping := func() *yaml.Node { return &yaml.Node{Kind: yaml.MappingNode} }
+	scalar := func(value string) *yaml.Node { return &yaml.Node{Kind: yaml.ScalarNode, Value: value} }
+	aliases := HostAliases(environments)
+	children := mapping()
+	for i, env := range environments {
+		hosts := mapping()
+		for j, machine := range env.Machines {
+			if machine.SSH == nil {
+				continue
+			}
+			vars := mapping()
+			for _, v := range ansibleVars(machine.SSH) {
+				value := scalar(v[1])
+				if v[0] == "ansible_port" {
+					value.Tag = "!!int"
+				}
+				vars.Content = append(vars.Content, scalar(v[0]), value)
+			}
+			hosts.Content = append(hosts.Content, scalar(aliases[i][j]), vars)
+		}
+		if len(hosts.Content) == 0 {
+			continue
+		}
+		group := mapping()
+		group.Content = append(group.Content, scalar("hosts"), hosts)
+		children.Content = append(children.Content, scalar(ansibleGroup(env)), group)
+	}
+	all := mapping()
+	all.Content = append(all.Content, scalar("children"), children)
+	root := mapping()
+	root.Content = append(root.Content, scalar("all"), all)
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
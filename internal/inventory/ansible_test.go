@@ -0,0 +1,108 @@
+package inventory
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/braheezy/violet/pkg/vagrant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostAlias(t *testing.T) {
+	assert.Equal(t, "app-web", HostAlias(Environment{Name: "app"}, Machine{Name: "web"}))
+	assert.Equal(t, "foo-env1-web", HostAlias(Environment{Name: "foo/env1"}, Machine{Name: "web"}))
+	assert.Equal(t, "my-env-web.1", HostAlias(Environment{Name: "my env"}, Machine{Name: "web.1"}))
+}
+
+func TestHostAliases(t *testing.T) {
+	environments := []Environment{
+		{Name: "foo", Machines: []Machine{{Name: "bar-baz"}, {Name: "web"}}},
+		{Name: "foo-bar", Machines: []Machine{{Name: "baz"}, {Name: "baz-2"}}},
+		{Name: "foo/bar", Machines: []Machine{{Name: "baz"}}},
+	}
+	assert.Equal(t, [][]string{
+		{"foo-bar-baz", "foo-web"},
+		{"foo-bar-baz-3", "foo-bar-baz-2"},
+		{"foo-bar-baz-4"},
+	}, HostAliases(environments), "Verify machines with the same alias are told apart, without taking another's")
+}
+
+func TestLoadSSH(t *testing.T) {
+	sim := vagrant.NewSimulator(
+		vagrant.SimulatedMachine{ID: "1a2b3c4", Name: "web", Provider: "virtualbox", State: "running", Home: "/projects/site"},
+		vagrant.SimulatedMachine{ID: "5d6e7f8", Name: "db", Provider: "virtualbox", State: "poweroff", Home: "/projects/site"},
+	)
+	environments, err := Load(context.Background(), sim)
+	require.NoError(t, err)
+
+	require.NoError(t, LoadSSH(context.Background(), sim, environments))
+	require.NotNil(t, environments[0].Machines[0].SSH)
+	ssh := *environments[0].Machines[0].SSH
+	assert.Len(t, ssh.Options, 5, "Verify every option is kept for SSH configs")
+	ssh.Options = nil
+	assert.Equal(t, SSH{
+		Host:         "127.0.0.1",
+		Port:         2222,
+		User:         "vagrant",
+		IdentityFile: "/projects/site/.vagrant/machines/web/virtualbox/private_key",
+	}, ssh)
+	assert.Nil(t, environments[0].Machines[1].SSH, "Verify machines that aren't running are skipped")
+
+	// The index says it's running, but it's not ready yet
+	_, err = sim.Run(context.Background(), "", "halt", "1a2b3c4")
+	require.NoError(t, err)
+	environments[0].Machines[0].SSH = nil
+	require.NoError(t, LoadSSH(context.Background(), sim, environments))
+	assert.Nil(t, environments[0].Machines[0].SSH)
+}
+
+func TestWriteAnsible(t *testing.T) {
+	environments := []Environment{
+		{Name: "site", Machines: []Machine{
+			{Name: "web", SSH: &SSH{Host: "127.0.0.1", Port: 2222, User: "vagrant", IdentityFile: "/projects/my site/private_key"}},
+			{Name: "db", SSH: &SSH{Host: "192.168.121.10", Port: 22, User: "vagrant"}},
+		}},
+		{Name: "stopped", Machines: []Machine{{Name: "default"}}},
+		{Name: "foo/2024", Machines: []Machine{
+			{Name: "default", SSH: &SSH{Host: "127.0.0.1", Port: 2200, User: "vagrant"}},
+		}},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, WriteAnsible(&out, "ini", environments))
+	assert.Equal(t, `[site]
+site-web ansible_host=127.0.0.1 ansible_port=2222 ansible_user=vagrant ansible_ssh_private_key_file="/projects/my site/private_key"
+site-db ansible_host=192.168.121.10 ansible_port=22 ansible_user=vagrant
+
+[foo_2024]
+foo-2024-default ansible_host=127.0.0.1 ansible_port=2200 ansible_user=vagrant
+`, out.String())
+
+	out.Reset()
+	require.NoError(t, WriteAnsible(&out, "yaml", environments))
+	assert.Equal(t, `all:
+  children:
+    site:
+      hosts:
+        site-web:
+          ansible_host: 127.0.0.1
+          ansible_port: 2222
+          ansible_user: vagrant
+          ansible_ssh_private_key_file: /projects/my site/private_key
+        site-db:
+          ansible_host: 192.168.121.10
+          ansible_port: 22
+          ansible_user: vagrant
+    foo_2024:
+      hosts:
+        foo-2024-default:
+          ansible_host: 127.0.0.1
+          ansible_port: 2200
+          ansible_user: vagrant
+`, out.String())
+
+	assert.Equal(t, "env_2024", ansibleGroup(Environment{Name: "2024"}), "Verify groups don't start with a digit")
+	assert.EqualError(t, WriteAnsible(&out, "json", environments), `unknown format "json"`)
+}
@@ -0,0 +1,42 @@
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Formats that Write supports.
+var Formats = []string{"json", "yaml"}
+
+// Write v to w as format, one of Formats. The output only changes when v does,
+// so it can be diffed and checked in.
+func Write(w io.Writer, format string, v any) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	case "yaml":
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(v); err != nil {
+			return err
+		}
+		return encoder.Close()
+	}
+	return fmt.Errorf("unknown format %q", format)
+}
+
+// FormatOf guesses the format of a file from its extension, JSON unless it's YAML.
+func FormatOf(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return "yaml"
+	}
+	return "json"
+}
@@ -0,0 +1,62 @@
+package inventory
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	environments := []Environment{{
+		Name: "site",
+		Home: "/projects/site",
+		Machines: []Machine{
+			{ID: "1a2b3c4", Name: "web", Provider: "virtualbox", State: "running", Home: "/projects/site",
+				SSH: &SSH{Host: "127.0.0.1", Port: 2222, User: "vagrant"}},
+			{ID: "5d6e7f8", Name: "db", Provider: "virtualbox", State: "poweroff", Home: "/projects/site"},
+		},
+	}}
+
+	var out bytes.Buffer
+	require.NoError(t, Write(&out, "yaml", environments))
+	assert.Equal(t, `- name: site
+  home: /projects/site
+  machines:
+    - id: 1a2b3c4
+      name: web
+      provider: virtualbox
+      state: running
+      home: /projects/site
+      ssh:
+        host: 127.0.0.1
+        port: 2222
+        user: vagrant
+    - id: 5d6e7f8
+      name: db
+      provider: virtualbox
+      state: poweroff
+      home: /projects/site
+`, out.String())
+
+	out.Reset()
+	require.NoError(t, Write(&out, "json", environments[0].Machines[1]))
+	assert.Equal(t, `{
+  "id": "5d6e7f8",
+  "name": "db",
+  "provider": "virtualbox",
+  "state": "poweroff",
+  "home": "/projects/site"
+}
+`, out.String(), "Verify unknown SSH details are left out")
+
+	assert.EqualError(t, Write(&out, "xml", environments), `unknown format "xml"`)
+}
+
+func TestFormatOf(t *testing.T) {
+	assert.Equal(t, "yaml", FormatOf("inventory.yaml"))
+	assert.Equal(t, "yaml", FormatOf("/tmp/inventory.YML"))
+	assert.Equal(t, "json", FormatOf("inventory.json"))
+	assert.Equal(t, "json", FormatOf("inventory"))
+}
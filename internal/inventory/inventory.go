@@ -0,0 +1,209 @@
+// Package inventory groups the machines Vagrant knows about into environments
+// and finds them again by what the user calls them. The TUI and the CLI see
+// the same environments, with the same names.
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/braheezy/violet/pkg/vagrant"
+)
+
+// Environment is a Vagrant project and the machines in it.
+type Environment struct {
+	// Name is the shortest suffix of Home that's unique among all environments.
+	Name string `json:"name" yaml:"name"`
+	// Home is the absolute path to the project. This is what identifies the environment.
+	Home     string    `json:"home" yaml:"home"`
+	Machines []Machine `json:"machines" yaml:"machines"`
+}
+
+// Machine is a single Vagrant machine.
+type Machine struct {
+	ID       string `json:"id" yaml:"id"`
+	Name     string `json:"name" yaml:"name"`
+	Provider string `json:"provider" yaml:"provider"`
+	// State is as Vagrant reports it e.g. not_created.
+	State string `json:"state" yaml:"state"`
+	Home  string `json:"home" yaml:"home"`
+	// SSH is how to connect to the machine, if that's known.
+	SSH *SSH `json:"ssh,omitempty" yaml:"ssh,omitempty"`
+}
+
+// SSH is what's needed to connect to a machine without Vagrant.
+type SSH struct {
+	Host         string `json:"host" yaml:"host"`
+	Port         int    `json:"port" yaml:"port"`
+	User         string `json:"user" yaml:"user"`
+	IdentityFile string `json:"identity_file,omitempty" yaml:"identity_file,omitempty"`
+	// Options are everything Vagrant said to connect with, for writing SSH configs.
+	Options []vagrant.SSHOption `json:"-" yaml:"-"`
+}
+
+// Load gets every machine runner knows about, grouped into environments.
+func Load(ctx context.Context, runner vagrant.Runner) ([]Environment, error) {
+	entries, err := runner.GlobalStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return Group(entries), nil
+}
+
+// Group machines into environments by their home, in the order each home is first seen.
+// The full path is the identity, folder names are often reused e.g. many `vagrant/` folders in a monorepo.
+func Group(entries []vagrant.GlobalStatusEntry) []Environment {
+	var environments []Environment
+	index := make(map[string]int)
+	for _, entry := range entries {
+		machine := Machine{
+			ID:       entry.MachineID,
+			Name:     entry.Name,
+			Provider: entry.Provider,
+			State:    entry.State,
+			Home:     filepath.Clean(entry.Home),
+		}
+		i, ok := index[machine.Home]
+		if !ok {
+			i = len(environments)
+			index[machine.Home] = i
+			environments = append(environments, Environment{Home: machine.Home})
+		}
+		environments[i].Machines = append(environments[i].Machines, machine)
+	}
+
+	homes := make([]string, len(environments))
+	for i, env := range environments {
+		homes[i] = env.Home
+	}
+	for i, label := range ShortestUniqueSuffixes(homes) {
+		environments[i].Name = label
+	}
+	return environments
+}
+
+// ShortestUniqueSuffixes labels each path with the fewest trailing path elements
+// that tell it apart from the others e.g. /foo/env1 and /bar/env1 become foo/env1 and bar/env1.
+func ShortestUniqueSuffixes(paths []string) []string {
+	elements := make([][]string, len(paths))
+	depths := make([]int, len(paths))
+	for i, p := range paths {
+		elements[i] = strings.Split(strings.Trim(filepath.ToSlash(p), "/"), "/")
+		depths[i] = 1
+	}
+	suffix := func(i int) string {
+		start := max(len(elements[i])-depths[i], 0)
+		return strings.Join(elements[i][start:], "/")
+	}
+
+	for {
+		// Find the labels that are still ambiguous and lengthen them
+		seen := make(map[string][]int)
+		for i := range paths {
+			seen[suffix(i)] = append(seen[suffix(i)], i)
+		}
+		changed := false
+		for _, indexes := range seen {
+			if len(indexes) < 2 {
+				continue
+			}
+			for _, i := range indexes {
+				if depths[i] < len(elements[i]) {
+					depths[i]++
+					changed = true
+				}
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+
+	labels := make([]string, len(paths))
+	for i := range paths {
+		labels[i] = suffix(i)
+	}
+	return labels
+}
+
+// Selection is what a selector found: a whole environment, or one machine in it.
+type Selection struct {
+	Environment Environment
+	// Machine is nil when the whole environment was selected.
+	Machine *Machine
+}
+
+// Target describes the selection for people e.g. app/web.
+func (s Selection) Target() string {
+	if s.Machine == nil {
+		return s.Environment.Name
+	}
+	return s.Environment.Name + "/" + s.Machine.Name
+}
+
+// NotFoundError is returned when a selector doesn't match anything.
+type NotFoundError struct{ Selector string }
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("nothing called %q, see `violet list`", e.Selector)
+}
+
+// AmbiguousError is returned when a selector matches more than one thing.
+type AmbiguousError struct {
+	Selector string
+	// Matches are the targets it could mean.
+	Matches []string
+}
+
+func (e *AmbiguousError) Error() string {
+	return fmt.Sprintf("%q could mean any of %v", e.Selector, strings.Join(e.Matches, ", "))
+}
+
+// Select finds what selector means. From most to least specific, it's one of:
+//   - an environment's name or home
+//   - a machine as <environment>/<machine>
+//   - a machine ID, or the start of one
+//   - a machine name, if only one environment has a machine called that
+func Select(environments []Environment, selector string) (Selection, error) {
+	if selector == "" {
+		return Selection{}, &NotFoundError{Selector: selector}
+	}
+	for _, env := range environments {
+		if selector == env.Name || (filepath.IsAbs(selector) && filepath.Clean(selector) == env.Home) {
+			return Selection{Environment: env}, nil
+		}
+	}
+
+	matchers := []func(env Environment, machine Machine) bool{
+		func(env Environment, machine Machine) bool { return selector == env.Name+"/"+machine.Name },
+		func(env Environment, machine Machine) bool {
+			return machine.ID != "" && strings.HasPrefix(machine.ID, selector)
+		},
+		func(env Environment, machine Machine) bool { return selector == machine.Name },
+	}
+	for _, matches := range matchers {
+		var found []Selection
+		for _, env := range environments {
+			for _, machine := range env.Machines {
+				if matches(env, machine) {
+					found = append(found, Selection{Environment: env, Machine: &machine})
+				}
+			}
+		}
+		switch len(found) {
+		case 0:
+			continue
+		case 1:
+			return found[0], nil
+		default:
+			err := &AmbiguousError{Selector: selector}
+			for _, selection := range found {
+				err.Matches = append(err.Matches, selection.Target())
+			}
+			return Selection{}, err
+		}
+	}
+	return Selection{}, &NotFoundError{Selector: selector}
+}
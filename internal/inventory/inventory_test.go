@@ -0,0 +1,110 @@
+package inventory
+
+import (
+	"testing"
+
+	"github.com/braheezy/violet/pkg/vagrant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShortestUniqueSuffixes(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []string
+		expected []string
+	}{
+		{
+			name:     "Verify distinct folder names",
+			input:    []string{"/home/braheezy/env1", "/home/braheezy/env2"},
+			expected: []string{"env1", "env2"},
+		},
+		{
+			name:     "Verify same folder names",
+			input:    []string{"/foo/env1", "/bar/env1", "/bar/env2"},
+			expected: []string{"foo/env1", "bar/env1", "env2"},
+		},
+		{
+			name:     "Verify deeply nested collisions",
+			input:    []string{"/repo/a/app/vagrant", "/repo/b/app/vagrant", "/repo/b/db/vagrant"},
+			expected: []string{"a/app/vagrant", "b/app/vagrant", "db/vagrant"},
+		},
+		{
+			name:     "Verify a path that is a suffix of another",
+			input:    []string{"/env1", "/foo/env1"},
+			expected: []string{"env1", "foo/env1"},
+		},
+		{
+			name:     "Verify empty input",
+			input:    nil,
+			expected: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, ShortestUniqueSuffixes(test.input), test.name)
+	}
+}
+
+func TestGroup(t *testing.T) {
+	environments := Group([]vagrant.GlobalStatusEntry{
+		{MachineID: "1a2b3c4", Name: "web", Provider: "libvirt", State: "running", Home: "/foo/env1"},
+		{MachineID: "5d6e7f8", Name: "web", Provider: "virtualbox", State: "not_created", Home: "/bar/env1/"},
+		{MachineID: "9a8b7c6", Name: "db", Provider: "libvirt", State: "poweroff", Home: "/foo/env1"},
+	})
+	assert.Equal(t, []Environment{
+		{Name: "foo/env1", Home: "/foo/env1", Machines: []Machine{
+			{ID: "1a2b3c4", Name: "web", Provider: "libvirt", State: "running", Home: "/foo/env1"},
+			{ID: "9a8b7c6", Name: "db", Provider: "libvirt", State: "poweroff", Home: "/foo/env1"},
+		}},
+		{Name: "bar/env1", Home: "/bar/env1", Machines: []Machine{
+			{ID: "5d6e7f8", Name: "web", Provider: "virtualbox", State: "not_created", Home: "/bar/env1"},
+		}},
+	}, environments)
+	assert.Empty(t, Group(nil))
+}
+
+func TestSelect(t *testing.T) {
+	environments := Group([]vagrant.GlobalStatusEntry{
+		{MachineID: "1a2b3c4", Name: "web", Home: "/foo/env1"},
+		{MachineID: "1a9f8e7", Name: "db", Home: "/foo/env1"},
+		{MachineID: "5d6e7f8", Name: "web", Home: "/bar/env1"},
+		{MachineID: "9a8b7c6", Name: "cache", Home: "/bar/env1"},
+	})
+
+	tests := []struct {
+		name     string
+		selector string
+		expected string
+	}{
+		{"Verify environments by name", "foo/env1", "foo/env1"},
+		{"Verify environments by home", "/bar/env1/", "bar/env1"},
+		{"Verify machines by environment and name", "bar/env1/web", "bar/env1/web"},
+		{"Verify machines by ID", "5d6e7f8", "bar/env1/web"},
+		{"Verify machines by the start of their ID", "1a2", "foo/env1/web"},
+		{"Verify machines by a name only one has", "cache", "bar/env1/cache"},
+	}
+	for _, test := range tests {
+		selection, err := Select(environments, test.selector)
+		require.NoError(t, err, test.name)
+		assert.Equal(t, test.expected, selection.Target(), test.name)
+	}
+
+	selection, err := Select(environments, "db")
+	require.NoError(t, err)
+	assert.Equal(t, "1a9f8e7", selection.Machine.ID)
+	assert.Equal(t, "/foo/env1", selection.Environment.Home)
+
+	_, err = Select(environments, "web")
+	var ambiguous *AmbiguousError
+	require.ErrorAs(t, err, &ambiguous, "Verify names used twice are ambiguous")
+	assert.Equal(t, []string{"foo/env1/web", "bar/env1/web"}, ambiguous.Matches)
+	_, err = Select(environments, "1a")
+	assert.ErrorAs(t, err, &ambiguous, "Verify IDs are ambiguous until they're long enough")
+
+	var notFound *NotFoundError
+	_, err = Select(environments, "env2")
+	assert.ErrorAs(t, err, &notFound)
+	_, err = Select(environments, "")
+	assert.ErrorAs(t, err, &notFound)
+}
@@ -0,0 +1,149 @@
+package inventory
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/braheezy/violet/pkg/vagrant"
+)
+
+// The lines around what violet writes to an SSH config. Everything between them is replaced
+// each time, everything else is left alone.
+const (
+	managedBlockBegin = "# BEGIN violet managed block, changes here will be overwritten"
+	managedBlockEnd   = "# END violet managed block"
+)
+
+// DefaultSSHConfigPath is the user's own SSH config, ~/.ssh/config.
+func DefaultSSHConfigPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".ssh", "config"), nil
+}
+
+// WriteSSHConfig writes a Host block to w for each machine with SSH, see LoadSSH.
+// Machines are called by their HostAliases so they can be told apart across environments.
+func WriteSSHConfig(w io.Writer, environments []Environment) error {
+	aliases := HostAliases(environments)
+	var b strings.Builder
+	for i, env := range environments {
+		for j, machine := range env.Machines {
+			if machine.SSH == nil {
+				continue
+			}
+			if b.Len() > 0 {
+				b.WriteString("\n")
+			}
+			fmt.Fprintf(&b, "Host %v\n", aliases[i][j])
+			for _, option := range sshOptions(machine.SSH) {
+				argument := option.Argument
+				if strings.ContainsAny(argument, " \t") {
+					argument = strconv.Quote(argument)
+				}
+				fmt.Fprintf(&b, "  %v %v\n", option.Keyword, argument)
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Everything Vagrant said to connect with, or the basics when that's not known.
+func sshOptions(ssh *SSH) []vagrant.SSHOption {
+	if len(ssh.Options) > 0 {
+		return ssh.Options
+	}
+	options := []vagrant.SSHOption{
+		{Keyword: "HostName", Argument: ssh.Host},
+		{Keyword: "User", Argument: ssh.User},
+		{Keyword: "Port", Argument: strconv.Itoa(ssh.Port)},
+	}
+	if ssh.IdentityFile != "" {
+		options = append(options, vagrant.SSHOption{Keyword: "IdentityFile", Argument: ssh.IdentityFile})
+	}
+	return options
+}
+
+// UpdateSSHConfig writes the Host blocks from WriteSSHConfig into violet's managed block
+// of the SSH config at path. The rest of the file is kept as it is. The file is created
+// if it doesn't exist, which is how a separate file to Include is made.
+func UpdateSSHConfig(path string, environments []Environment) error {
+	// Dotfile managers often link the config, so update what's linked to
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	mode := fs.FileMode(0o600)
+	existing, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	} else if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	var block strings.Builder
+	if err := WriteSSHConfig(&block, environments); err != nil {
+		return err
+	}
+	updated := replaceManagedBlock(string(existing), block.String())
+
+	// Write it all or nothing, a half written SSH config breaks every connection
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(updated); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(mode); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// Put block between the managed block lines in config, replacing what was there.
+// The managed block is added to the end if config doesn't have one yet. Each end line
+// goes with the nearest begin line before it, so a begin line left without an end
+// doesn't take the user's own lines after it into the block.
+func replaceManagedBlock(config string, block string) string {
+	managed := managedBlockBegin + "\n" + block + managedBlockEnd + "\n"
+	lines := strings.SplitAfter(config, "\n")
+	begin, end := -1, -1
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == managedBlockBegin {
+			begin = i
+		} else if trimmed == managedBlockEnd && begin >= 0 {
+			end = i
+			break
+		}
+	}
+	if begin >= 0 && end >= 0 {
+		return strings.Join(lines[:begin], "") + managed + strings.Join(lines[end+1:], "")
+	}
+
+	if config != "" && !strings.HasSuffix(config, "\n") {
+		config += "\n"
+	}
+	if config != "" {
+		config += "\n"
+	}
+	return config + managed
+}
@@ -0,0 +1,105 @@
+package inventory
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/braheezy/violet/pkg/vagrant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var sshEnvironments = []Environment{
+	{Name: "site", Machines: []Machine{
+		{Name: "web", SSH: &SSH{Options: []vagrant.SSHOption{
+			{Keyword: "HostName", Argument: "127.0.0.1"},
+			{Keyword: "Port", Argument: "2222"},
+			{Keyword: "IdentityFile", Argument: "/projects/my site/private_key"},
+			{Keyword: "IdentitiesOnly", Argument: "yes"},
+		}}},
+		{Name: "db"},
+	}},
+	{Name: "foo/tool", Machines: []Machine{
+		{Name: "default", SSH: &SSH{Host: "127.0.0.1", Port: 2200, User: "vagrant"}},
+	}},
+}
+
+func TestWriteSSHConfig(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, WriteSSHConfig(&out, sshEnvironments))
+	assert.Equal(t, `Host site-web
+  HostName 127.0.0.1
+  Port 2222
+  IdentityFile "/projects/my site/private_key"
+  IdentitiesOnly yes
+
+Host foo-tool-default
+  HostName 127.0.0.1
+  User vagrant
+  Port 2200
+`, out.String())
+}
+
+func TestReplaceManagedBlock(t *testing.T) {
+	block := "Host site-web\n  Port 2222\n"
+	managed := managedBlockBegin + "\n" + block + managedBlockEnd + "\n"
+	tests := []struct {
+		name     string
+		config   string
+		expected string
+	}{
+		{"Verify an empty config", "", managed},
+		{"Verify the block is added to the end", "Host github.com\n  User git", "Host github.com\n  User git\n\n" + managed},
+		{
+			"Verify the block is replaced and nothing else is",
+			"Include ~/.ssh/work\n\n" + managedBlockBegin + "\nHost old\n" + managedBlockEnd + "\n\nHost *\n  ForwardAgent no\n",
+			"Include ~/.ssh/work\n\n" + managed + "\nHost *\n  ForwardAgent no\n",
+		},
+		{
+			"Verify a block without an end isn't replaced",
+			managedBlockBegin + "\nHost mine\n",
+			managedBlockBegin + "\nHost mine\n\n" + managed,
+		},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, replaceManagedBlock(test.config, block), test.name)
+	}
+
+	// Updating again pairs the end with the block added last time, not the begin without one
+	orphaned := managedBlockBegin + "\nHost mine\n  User me\n"
+	config := replaceManagedBlock(orphaned, "Host old\n")
+	config = replaceManagedBlock(config, block)
+	assert.Equal(t, orphaned+"\n"+managed, config, "Verify the user's lines after a begin without an end are kept")
+}
+
+func TestUpdateSSHConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".ssh", "violet.conf")
+	require.NoError(t, UpdateSSHConfig(path, sshEnvironments))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm(), "Verify new configs are private")
+
+	// Kept in a dotfiles repo and linked
+	target := filepath.Join(dir, "dotfiles", "ssh_config")
+	require.NoError(t, os.MkdirAll(filepath.Dir(target), 0o755))
+	require.NoError(t, os.WriteFile(target, []byte("Host github.com\n  User git\n"), 0o644))
+	link := filepath.Join(dir, ".ssh", "config")
+	require.NoError(t, os.Symlink(target, link))
+
+	require.NoError(t, UpdateSSHConfig(link, sshEnvironments[:1]))
+	require.NoError(t, UpdateSSHConfig(link, sshEnvironments))
+	resolved, err := os.Readlink(link)
+	require.NoError(t, err)
+	assert.Equal(t, target, resolved, "Verify the link is kept")
+	data, err := os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, 1, bytes.Count(data, []byte("Host site-web")), "Verify the block is replaced, not added again")
+	assert.Contains(t, string(data), "Host foo-tool-default")
+	assert.Contains(t, string(data), "Host github.com\n  User git\n")
+	info, err = os.Stat(target)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o644), info.Mode().Perm(), "Verify the mode is kept")
+}
@@ -0,0 +1,160 @@
+package vagrant
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+// OutdatedBox is a box with a newer version available, as reported by `vagrant box outdated --global`.
+type OutdatedBox struct {
+	Name     string
+	Provider string
+	Current  string
+	Latest   string
+}
+
+var (
+	// e.g. * 'bento/ubuntu-22.04' for 'virtualbox' (amd64) is outdated! Current: 202309.08.0. Latest: 202401.31.0
+	outdatedBoxLine = regexp.MustCompile(`^\* '([^']+)' for '([^']+)'.* is outdated! Current: (\S+)\. Latest: (\S+)$`)
+	// e.g. Would remove bento/ubuntu-22.04 virtualbox 202309.08.0
+	wouldRemoveBoxLine = regexp.MustCompile(`^Would remove (\S+) (\S+) (\S+)`)
+	// e.g. Removing box 'bento/ubuntu-22.04' (v202309.08.0) with provider 'virtualbox'...
+	removingBoxLine = regexp.MustCompile(`^Removing box '([^']+)' \(v([^)]+)\) with provider '([^']+)'`)
+)
+
+// Every line of text Vagrant printed for humans in output
+func uiLines(output string) []string {
+	var lines []string
+	for _, event := range ParseEvents(output) {
+		if event.Type == "ui" {
+			for _, line := range strings.Split(event.Value(1), "\n") {
+				lines = append(lines, strings.TrimSpace(line))
+			}
+		}
+	}
+	return lines
+}
+
+// ParseOutdatedBoxes turns the output of `vagrant box outdated --global --machine-readable`
+// into the boxes that have newer versions. Up to date boxes aren't included.
+func ParseOutdatedBoxes(output string) []OutdatedBox {
+	var boxes []OutdatedBox
+	for _, line := range uiLines(output) {
+		if m := outdatedBoxLine.FindStringSubmatch(line); m != nil {
+			boxes = append(boxes, OutdatedBox{
+				Name:     m[1],
+				Provider: m[2],
+				Current:  m[3],
+				Latest:   strings.TrimSuffix(m[4], "."),
+			})
+		}
+	}
+	return boxes
+}
+
+// ParsePrunedBoxes turns the output of `vagrant box prune --machine-readable` into the
+// boxes that were removed, or would be removed with --dry-run.
+func ParsePrunedBoxes(output string) []Box {
+	var boxes []Box
+	for _, line := range uiLines(output) {
+		if m := wouldRemoveBoxLine.FindStringSubmatch(line); m != nil {
+			boxes = append(boxes, Box{Name: m[1], Provider: m[2], Version: m[3]})
+		} else if m := removingBoxLine.FindStringSubmatch(line); m != nil {
+			boxes = append(boxes, Box{Name: m[1], Version: m[2], Provider: m[3]})
+		}
+	}
+	return boxes
+}
+
+// ListBoxes returns every box installed on the host.
+func ListBoxes(ctx context.Context, r Runner) ([]Box, error) {
+	output, err := r.Run(ctx, "", "box", "list", "--machine-readable")
+	if err != nil {
+		return nil, err
+	}
+	return ParseBoxList(output), nil
+}
+
+// OutdatedBoxes checks every installed box for newer versions. This goes out to the network.
+func OutdatedBoxes(ctx context.Context, r Runner) ([]OutdatedBox, error) {
+	output, err := r.Run(ctx, "", "box", "outdated", "--global", "--machine-readable")
+	if err != nil {
+		return nil, err
+	}
+	return ParseOutdatedBoxes(output), nil
+}
+
+// UpdateBoxArgs are the arguments to update the box called name for provider.
+func UpdateBoxArgs(name string, provider string) []string {
+	return []string{"box", "update", "--box", name, "--provider", provider, "--machine-readable"}
+}
+
+// UpdateBox downloads the latest version of the box called name for provider.
+func UpdateBox(ctx context.Context, r Runner, name string, provider string) error {
+	_, err := r.Run(ctx, "", UpdateBoxArgs(name, provider)...)
+	return err
+}
+
+// PruneBoxesArgs are the arguments to remove old versions of boxes. Boxes in
+// use by an environment are always kept, so Vagrant never has to ask.
+func PruneBoxesArgs(dryRun bool) []string {
+	args := []string{"box", "prune", "--keep-active-boxes"}
+	if dryRun {
+		args = append(args, "--dry-run")
+	}
+	return append(args, "--machine-readable")
+}
+
+// PruneBoxes removes old versions of installed boxes and returns what was removed.
+// With dryRun, nothing is removed and the result is what would have been.
+func PruneBoxes(ctx context.Context, r Runner, dryRun bool) ([]Box, error) {
+	output, err := r.Run(ctx, "", PruneBoxesArgs(dryRun)...)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePrunedBoxes(output), nil
+}
+
+// RemoveBoxArgs are the arguments to remove a single version of a box.
+func RemoveBoxArgs(box Box) []string {
+	args := []string{"box", "remove", box.Name, "--provider", box.Provider, "--box-version", box.Version}
+	if box.Architecture != "" {
+		args = append(args, "--architecture", box.Architecture)
+	}
+	return append(args, "--machine-readable")
+}
+
+// RemoveBox removes a single version of a box. Boxes in use by an environment
+// make Vagrant ask for confirmation, which fails without a terminal.
+func RemoveBox(ctx context.Context, r Runner, box Box) error {
+	_, err := r.Run(ctx, "", RemoveBoxArgs(box)...)
+	return err
+}
+
+// ListBoxes returns every box installed on the host.
+func (c *VagrantClient) ListBoxes(ctx context.Context) ([]Box, error) {
+	return ListBoxes(ctx, c)
+}
+
+// OutdatedBoxes checks every installed box for newer versions. This goes out to the network.
+func (c *VagrantClient) OutdatedBoxes(ctx context.Context) ([]OutdatedBox, error) {
+	return OutdatedBoxes(ctx, c)
+}
+
+// UpdateBox downloads the latest version of the box called name for provider.
+func (c *VagrantClient) UpdateBox(ctx context.Context, name string, provider string) error {
+	return UpdateBox(ctx, c, name, provider)
+}
+
+// PruneBoxes removes old versions of installed boxes and returns what was removed.
+// With dryRun, nothing is removed and the result is what would have been.
+func (c *VagrantClient) PruneBoxes(ctx context.Context, dryRun bool) ([]Box, error) {
+	return PruneBoxes(ctx, c, dryRun)
+}
+
+// RemoveBox removes a single version of a box. Boxes in use by an environment
+// make Vagrant ask for confirmation, which fails without a terminal.
+func (c *VagrantClient) RemoveBox(ctx context.Context, box Box) error {
+	return RemoveBox(ctx, c, box)
+}
@@ -0,0 +1,107 @@
+package vagrant
+
+import (
+	"context"
+	"testing"
+
+	"github.com/braheezy/violet/pkg/vagrant/vagranttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOutdatedBoxes(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []OutdatedBox
+	}{
+		{
+			name: "Verify outdated boxes are found",
+			input: `1695000000,,ui,info,* 'bento/ubuntu-22.04' for 'virtualbox' is outdated! Current: 202309.08.0. Latest: 202401.31.0
+			1695000000,,ui,info,* 'generic/fedora38' for 'libvirt' (v4.3.4) is up to date
+			1695000000,,ui,info,* 'hashicorp/bionic64' for 'virtualbox' wasn't added from a catalog%!(VAGRANT_COMMA) no version information`,
+			expected: []OutdatedBox{
+				{Name: "bento/ubuntu-22.04", Provider: "virtualbox", Current: "202309.08.0", Latest: "202401.31.0"},
+			},
+		},
+		{
+			name:  "Verify architecture is skipped",
+			input: `1695000000,,ui,info,* 'bento/debian-12' for 'libvirt' (arm64) is outdated! Current: 1.0.0. Latest: 1.1.0.`,
+			expected: []OutdatedBox{
+				{Name: "bento/debian-12", Provider: "libvirt", Current: "1.0.0", Latest: "1.1.0"},
+			},
+		},
+		{
+			name:     "Verify up to date boxes",
+			input:    `1695000000,,ui,info,* 'generic/fedora38' for 'libvirt' (v4.3.4) is up to date`,
+			expected: nil,
+		},
+	}
+
+	for _, test := range tests {
+		assert.EqualValues(t, test.expected, ParseOutdatedBoxes(test.input), test.name)
+	}
+}
+
+func TestParsePrunedBoxes(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []Box
+	}{
+		{
+			name: "Verify dry run",
+			input: `1695000000,,ui,info,The following boxes will be kept...
+			1695000000,,ui,info,bento/ubuntu-22.04 (virtualbox%!(VAGRANT_COMMA) 202401.31.0)
+			1695000000,,ui,info,
+			1695000000,,ui,info,Checking for older boxes...
+			1695000000,,ui,info,Would remove bento/ubuntu-22.04 virtualbox 202309.08.0`,
+			expected: []Box{
+				{Name: "bento/ubuntu-22.04", Provider: "virtualbox", Version: "202309.08.0"},
+			},
+		},
+		{
+			name:  "Verify removal",
+			input: `1695000000,,ui,info,Removing box 'bento/ubuntu-22.04' (v202309.08.0) with provider 'virtualbox'...`,
+			expected: []Box{
+				{Name: "bento/ubuntu-22.04", Provider: "virtualbox", Version: "202309.08.0"},
+			},
+		},
+		{
+			name:     "Verify nothing to prune",
+			input:    `1695000000,,ui,info,No old versions of boxes to remove...`,
+			expected: nil,
+		},
+	}
+
+	for _, test := range tests {
+		assert.EqualValues(t, test.expected, ParsePrunedBoxes(test.input), test.name)
+	}
+}
+
+func TestVagrantClientBoxes(t *testing.T) {
+	box := Box{Name: "bento/ubuntu-22.04", Provider: "virtualbox", Version: "202309.08.0"}
+	client, fake := newFakeClient(t, vagranttest.Script{
+		Commands: []vagranttest.Command{
+			{Args: []string{"box", "list", "--machine-readable"}},
+			{Args: []string{"box", "outdated", "--global", "--machine-readable"}, Output: "1695000000,,ui,info,* 'bento/ubuntu-22.04' for 'virtualbox' (amd64) is outdated! Current: 202309.08.0. Latest: 202401.31.0"},
+			{Args: UpdateBoxArgs(box.Name, box.Provider)},
+			{Args: PruneBoxesArgs(true), Output: "1695000000,,ui,info,Would remove bento/ubuntu-22.04 virtualbox 202309.08.0"},
+			{Args: RemoveBoxArgs(box)},
+		},
+	})
+	ctx := context.Background()
+
+	boxes, err := client.ListBoxes(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, boxes)
+	outdated, err := client.OutdatedBoxes(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []OutdatedBox{{Name: box.Name, Provider: box.Provider, Current: "202309.08.0", Latest: "202401.31.0"}}, outdated)
+	require.NoError(t, client.UpdateBox(ctx, box.Name, box.Provider))
+	pruned, err := client.PruneBoxes(ctx, true)
+	require.NoError(t, err)
+	assert.Equal(t, []Box{box}, pruned)
+	require.NoError(t, client.RemoveBox(ctx, box))
+	assert.Len(t, fake.Calls(t), 5)
+}
@@ -0,0 +1,110 @@
+package vagrant
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// SyncedFolder is a directory on the host shared with a machine.
+type SyncedFolder struct {
+	HostPath  string
+	GuestPath string
+	// Type is how the folder is shared e.g. "virtualbox", "rsync" or "nfs".
+	Type string
+}
+
+// GetMachineStatus returns the state of machine, a machine ID or a name in the environment in dir.
+func GetMachineStatus(ctx context.Context, r Runner, dir string, machine string) (MachineStatus, error) {
+	output, err := r.Status(ctx, dir, machine)
+	if err != nil {
+		return MachineStatus{}, err
+	}
+	statuses := ParseStatus(output)
+	if len(statuses) == 0 {
+		return MachineStatus{}, errors.New("vagrant didn't say what state " + machine + " is in")
+	}
+	return statuses[0], nil
+}
+
+// MachineDataDir is where Vagrant keeps what it knows about the machine called name
+// with provider, in the environment in home.
+func MachineDataDir(home string, name string, provider string) string {
+	return filepath.Join(home, ".vagrant", "machines", name, provider)
+}
+
+// ParseBoxMeta turns the box_meta file Vagrant writes for a machine into the box it was created from.
+func ParseBoxMeta(data []byte) (Box, error) {
+	var meta struct {
+		Name     string `json:"name"`
+		Version  string `json:"version"`
+		Provider string `json:"provider"`
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return Box{}, fmt.Errorf("Error parsing box_meta: %w", err)
+	}
+	return Box{Name: meta.Name, Provider: meta.Provider, Version: meta.Version}, nil
+}
+
+// ReadBoxMeta returns the box the machine with dataDir was created from, see MachineDataDir.
+// Machines created by Vagrant older than 2.2 don't record it.
+func ReadBoxMeta(dataDir string) (Box, error) {
+	data, err := os.ReadFile(filepath.Join(dataDir, "box_meta"))
+	if err != nil {
+		return Box{}, err
+	}
+	return ParseBoxMeta(data)
+}
+
+// ParseSyncedFolders turns the synced_folders file Vagrant writes for a machine into
+// its synced folders, ordered by guest path. Disabled folders are left out.
+func ParseSyncedFolders(data []byte) ([]SyncedFolder, error) {
+	// Folders by type, then by guest path
+	var byType map[string]map[string]struct {
+		HostPath  string `json:"hostpath"`
+		GuestPath string `json:"guestpath"`
+		Disabled  bool   `json:"disabled"`
+	}
+	if err := json.Unmarshal(data, &byType); err != nil {
+		return nil, fmt.Errorf("Error parsing synced_folders: %w", err)
+	}
+	var folders []SyncedFolder
+	for kind, byGuestPath := range byType {
+		for guestPath, folder := range byGuestPath {
+			if folder.Disabled {
+				continue
+			}
+			if folder.GuestPath != "" {
+				guestPath = folder.GuestPath
+			}
+			folders = append(folders, SyncedFolder{HostPath: folder.HostPath, GuestPath: guestPath, Type: kind})
+		}
+	}
+	sort.Slice(folders, func(i, j int) bool { return folders[i].GuestPath < folders[j].GuestPath })
+	return folders, nil
+}
+
+// ReadSyncedFolders returns the folders shared with the machine with dataDir, see MachineDataDir.
+// Vagrant only knows them once the machine has been brought up.
+func ReadSyncedFolders(dataDir string) ([]SyncedFolder, error) {
+	data, err := os.ReadFile(filepath.Join(dataDir, "synced_folders"))
+	if err != nil {
+		return nil, err
+	}
+	return ParseSyncedFolders(data)
+}
+
+// FindVagrantfile returns the path of the Vagrantfile of the environment in home.
+func FindVagrantfile(home string) (string, error) {
+	for _, name := range []string{"Vagrantfile", "vagrantfile"} {
+		path := filepath.Join(home, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", errors.New("no Vagrantfile in " + home)
+}
@@ -0,0 +1,83 @@
+package vagrant
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetMachineStatus(t *testing.T) {
+	sim := newTestSimulator()
+	ctx := context.Background()
+
+	status, err := GetMachineStatus(ctx, sim, "", "5d6")
+	require.NoError(t, err)
+	assert.Equal(t, "db", status.Name)
+	assert.Equal(t, "poweroff", status.State)
+
+	status, err = GetMachineStatus(ctx, sim, "/envs/site", "web")
+	require.NoError(t, err)
+	assert.Equal(t, "running", status.State, "Verify machines can be found by name in their environment")
+
+	_, err = GetMachineStatus(ctx, sim, "", "nope")
+	var commandErr *CommandError
+	require.ErrorAs(t, err, &commandErr)
+	assert.Equal(t, "Vagrant::Errors::MachineNotFound", commandErr.Class)
+}
+
+func TestParseBoxMeta(t *testing.T) {
+	box, err := ParseBoxMeta([]byte(`{"name":"bento/ubuntu-22.04","version":"202309.08.0","provider":"virtualbox","directory":"boxes/bento-VAGRANTSLASH-ubuntu-22.04/202309.08.0/virtualbox"}`))
+	require.NoError(t, err)
+	assert.Equal(t, Box{Name: "bento/ubuntu-22.04", Provider: "virtualbox", Version: "202309.08.0"}, box)
+
+	_, err = ParseBoxMeta([]byte("not json"))
+	assert.Error(t, err)
+}
+
+func TestParseSyncedFolders(t *testing.T) {
+	data := `{
+		"virtualbox": {
+			"/vagrant": {"guestpath": "/vagrant", "hostpath": "/envs/site", "disabled": false, "__vagrantfile": true},
+			"/srv/cache": {"guestpath": "/srv/cache", "hostpath": "/tmp/cache", "disabled": true}
+		},
+		"rsync": {
+			"/srv/app": {"guestpath": "/srv/app", "hostpath": "/envs/site/app", "disabled": false}
+		}
+	}`
+	folders, err := ParseSyncedFolders([]byte(data))
+	require.NoError(t, err)
+	assert.Equal(t, []SyncedFolder{
+		{HostPath: "/envs/site/app", GuestPath: "/srv/app", Type: "rsync"},
+		{HostPath: "/envs/site", GuestPath: "/vagrant", Type: "virtualbox"},
+	}, folders)
+}
+
+func TestReadMachineData(t *testing.T) {
+	home := t.TempDir()
+	dataDir := MachineDataDir(home, "web", "virtualbox")
+	assert.Equal(t, filepath.Join(home, ".vagrant", "machines", "web", "virtualbox"), dataDir)
+
+	_, err := ReadBoxMeta(dataDir)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	_, err = FindVagrantfile(home)
+	assert.Error(t, err)
+
+	require.NoError(t, os.MkdirAll(dataDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "box_meta"), []byte(`{"name":"generic/alpine318","version":"4.3.12","provider":"virtualbox"}`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "synced_folders"), []byte(`{"virtualbox":{"/vagrant":{"guestpath":"/vagrant","hostpath":"`+home+`"}}}`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(home, "Vagrantfile"), nil, 0o644))
+
+	box, err := ReadBoxMeta(dataDir)
+	require.NoError(t, err)
+	assert.Equal(t, "generic/alpine318", box.Name)
+	folders, err := ReadSyncedFolders(dataDir)
+	require.NoError(t, err)
+	assert.Equal(t, []SyncedFolder{{HostPath: home, GuestPath: "/vagrant", Type: "virtualbox"}}, folders)
+	vagrantfile, err := FindVagrantfile(home)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, "Vagrantfile"), vagrantfile)
+}
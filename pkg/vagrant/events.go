@@ -0,0 +1,305 @@
+package vagrant
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+Vagrant's --machine-readable output is documented here: https://developer.hashicorp.com/vagrant/docs/cli/machine-readable.
+
+Every line has the format:
+	timestamp,target,type,data...
+
+Commas, newlines and carriage returns inside the data are escaped as
+%!(VAGRANT_COMMA), \n and \r so each line splits cleanly on commas.
+*/
+
+// Event is a single line of machine-readable Vagrant output.
+type Event struct {
+	// Timestamp is when Vagrant printed the line.
+	Timestamp time.Time
+	// Target is the machine the event is about. Empty for global events.
+	Target string
+	// Type is the kind of event e.g. "ui", "state" or "box-name".
+	Type string
+	// Data holds the unescaped values following Type. Their meaning depends on Type.
+	Data []string
+}
+
+// Value returns the data at index i, or an empty string if there isn't any.
+func (e Event) Value(i int) string {
+	if i < len(e.Data) {
+		return e.Data[i]
+	}
+	return ""
+}
+
+// Machine-readable escape sequences and what they stand for.
+var unescaper = strings.NewReplacer(
+	"%!(VAGRANT_COMMA)", ",",
+	`\n`, "\n",
+	`\r`, "\r",
+)
+
+// Unescape reverses the escaping Vagrant applies to machine-readable data.
+func Unescape(data string) string {
+	return unescaper.Replace(data)
+}
+
+// ParseEvent parses a single line of machine-readable output.
+// Returns false if the line isn't machine-readable.
+func ParseEvent(line string) (Event, bool) {
+	parts := strings.Split(strings.TrimSpace(line), ",")
+	if len(parts) < 3 || parts[2] == "" {
+		return Event{}, false
+	}
+	seconds, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return Event{}, false
+	}
+
+	event := Event{
+		Timestamp: time.Unix(seconds, 0).UTC(),
+		Target:    parts[1],
+		Type:      parts[2],
+		Data:      make([]string, 0, len(parts)-3),
+	}
+	for _, data := range parts[3:] {
+		event.Data = append(event.Data, Unescape(data))
+	}
+	return event, true
+}
+
+// ParseEvents parses every machine-readable line in output, skipping any others.
+func ParseEvents(output string) []Event {
+	var events []Event
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if event, ok := ParseEvent(scanner.Text()); ok {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// ErrorExit describes the error Vagrant exited with.
+type ErrorExit struct {
+	// Class is the Ruby error class e.g. Vagrant::Errors::NoEnvironmentError
+	Class   string
+	Message string
+}
+
+// ParseErrorExit returns the error-exit event in output, if there is one.
+func ParseErrorExit(output string) (ErrorExit, bool) {
+	for _, event := range ParseEvents(output) {
+		if event.Type == "error-exit" {
+			return ErrorExit{Class: event.Value(0), Message: event.Value(1)}, true
+		}
+	}
+	return ErrorExit{}, false
+}
+
+// MachineStatus is the state of a machine as reported by `vagrant status`.
+type MachineStatus struct {
+	Name            string
+	Provider        string
+	State           string
+	StateHumanShort string
+	StateHumanLong  string
+}
+
+// ParseStatus turns the output of `vagrant status --machine-readable` into
+// one MachineStatus per machine, in the order they are first mentioned.
+func ParseStatus(output string) []MachineStatus {
+	var statuses []MachineStatus
+	index := make(map[string]int)
+	for _, event := range ParseEvents(output) {
+		if event.Target == "" {
+			continue
+		}
+		i, ok := index[event.Target]
+		if !ok {
+			i = len(statuses)
+			index[event.Target] = i
+			statuses = append(statuses, MachineStatus{Name: event.Target})
+		}
+		status := &statuses[i]
+		switch event.Type {
+		case "provider-name":
+			status.Provider = event.Value(0)
+		case "metadata":
+			if event.Value(0) == "provider" && status.Provider == "" {
+				status.Provider = event.Value(1)
+			}
+		case "state":
+			status.State = event.Value(0)
+		case "state-human-short":
+			status.StateHumanShort = event.Value(0)
+		case "state-human-long":
+			status.StateHumanLong = event.Value(0)
+		}
+	}
+	return statuses
+}
+
+// GlobalStatusEntry is a machine known to Vagrant, as reported by `vagrant global-status`.
+type GlobalStatusEntry struct {
+	// MachineID is the short unique ID of the machine.
+	MachineID string
+	// Name is only available from the human readable table Vagrant prints,
+	// and may be empty if that couldn't be understood.
+	Name     string
+	Provider string
+	State    string
+	// Home is the directory holding the machine's Vagrantfile.
+	Home string
+}
+
+// ParseGlobalStatus turns the output of `vagrant global-status --machine-readable`
+// into one GlobalStatusEntry per machine.
+func ParseGlobalStatus(output string) []GlobalStatusEntry {
+	var entries []GlobalStatusEntry
+	var table []string
+	inTable := false
+	for _, event := range ParseEvents(output) {
+		switch event.Type {
+		case "machine-id":
+			entries = append(entries, GlobalStatusEntry{MachineID: event.Value(0)})
+		case "provider-name", "machine-home", "state":
+			if len(entries) == 0 {
+				continue
+			}
+			entry := &entries[len(entries)-1]
+			switch event.Type {
+			case "provider-name":
+				entry.Provider = event.Value(0)
+			case "machine-home":
+				entry.Home = event.Value(0)
+			case "state":
+				entry.State = event.Value(0)
+			}
+		case "ui":
+			// The machine names are only in the table, which comes after a line of dashes.
+			text := event.Value(1)
+			if strings.HasPrefix(text, "---") {
+				inTable = true
+			} else if inTable {
+				table = append(table, text)
+			}
+		}
+	}
+
+	// Each table row starts with the machine ID, followed by its name.
+	for i := 0; i+1 < len(table); i++ {
+		for j := range entries {
+			if entries[j].MachineID == strings.TrimSpace(table[i]) {
+				entries[j].Name = strings.TrimSpace(table[i+1])
+			}
+		}
+	}
+	return entries
+}
+
+// Box is a base box installed on the host, as reported by `vagrant box list`.
+type Box struct {
+	Name     string
+	Provider string
+	Version  string
+	// Architecture is only reported by newer versions of Vagrant.
+	Architecture string
+}
+
+// ParseBoxList turns the output of `vagrant box list --machine-readable` into Boxes.
+func ParseBoxList(output string) []Box {
+	var boxes []Box
+	for _, event := range ParseEvents(output) {
+		if event.Type == "box-name" {
+			boxes = append(boxes, Box{Name: event.Value(0)})
+			continue
+		}
+		if len(boxes) == 0 {
+			continue
+		}
+		box := &boxes[len(boxes)-1]
+		switch event.Type {
+		case "box-provider":
+			box.Provider = event.Value(0)
+		case "box-version":
+			box.Version = event.Value(0)
+		case "box-architecture":
+			box.Architecture = event.Value(0)
+		}
+	}
+	return boxes
+}
+
+// SSHConfig is the OpenSSH configuration for a single host, as printed by `vagrant ssh-config`.
+type SSHConfig struct {
+	// Host is the alias Vagrant gave the machine, usually its name.
+	Host         string
+	HostName     string
+	User         string
+	Port         string
+	IdentityFile string
+	// Options holds every option in the block, including the ones above, in order.
+	Options []SSHOption
+}
+
+// SSHOption is a single keyword and its argument from an SSH config block.
+type SSHOption struct {
+	Keyword  string
+	Argument string
+}
+
+// ParseSSHConfig turns the output of `vagrant ssh-config` into one SSHConfig per host.
+// Output from --machine-readable is understood too.
+func ParseSSHConfig(output string) []SSHConfig {
+	var lines []string
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		if event, ok := ParseEvent(scanner.Text()); ok {
+			if event.Type == "ui" {
+				lines = append(lines, strings.Split(event.Value(1), "\n")...)
+			}
+		} else {
+			lines = append(lines, scanner.Text())
+		}
+	}
+
+	var configs []SSHConfig
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		keyword, argument, _ := strings.Cut(line, " ")
+		argument = strings.Trim(strings.TrimSpace(argument), `"`)
+		if strings.EqualFold(keyword, "Host") {
+			configs = append(configs, SSHConfig{Host: argument})
+			continue
+		}
+		if len(configs) == 0 {
+			continue
+		}
+		config := &configs[len(configs)-1]
+		config.Options = append(config.Options, SSHOption{Keyword: keyword, Argument: argument})
+		switch strings.ToLower(keyword) {
+		case "hostname":
+			config.HostName = argument
+		case "user":
+			config.User = argument
+		case "port":
+			config.Port = argument
+		case "identityfile":
+			// Vagrant lists the one it will use first
+			if config.IdentityFile == "" {
+				config.IdentityFile = argument
+			}
+		}
+	}
+	return configs
+}
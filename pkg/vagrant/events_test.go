@@ -0,0 +1,258 @@
+package vagrant
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEvent(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected Event
+		ok       bool
+	}{
+		{
+			name:  "Verify global event",
+			input: "1671330325,,metadata,machine-count,1",
+			expected: Event{
+				Timestamp: time.Unix(1671330325, 0).UTC(),
+				Type:      "metadata",
+				Data:      []string{"machine-count", "1"},
+			},
+			ok: true,
+		},
+		{
+			name:  "Verify escapes are undone",
+			input: `1,web,ui,info,One%!(VAGRANT_COMMA) two\nthree\rfour`,
+			expected: Event{
+				Timestamp: time.Unix(1, 0).UTC(),
+				Target:    "web",
+				Type:      "ui",
+				Data:      []string{"info", "One, two\nthree\rfour"},
+			},
+			ok: true,
+		},
+		{
+			name:  "Verify event without data",
+			input: "1,web,action",
+			expected: Event{
+				Timestamp: time.Unix(1, 0).UTC(),
+				Target:    "web",
+				Type:      "action",
+				Data:      []string{},
+			},
+			ok: true,
+		},
+		{
+			name:  "Verify human readable line",
+			input: "==> default: Booting VM...",
+			ok:    false,
+		},
+		{
+			name:  "Verify bad timestamp",
+			input: "now,,ui,info,hello",
+			ok:    false,
+		},
+	}
+
+	for _, test := range tests {
+		event, ok := ParseEvent(test.input)
+		assert.Equal(t, test.ok, ok, test.name)
+		assert.EqualValues(t, test.expected, event, test.name)
+	}
+}
+
+func TestParseErrorExit(t *testing.T) {
+	input := `1671329290,,ui,error,A Vagrant environment or target machine is required
+	1671329290,,error-exit,Vagrant::Errors::NoEnvironmentError,A Vagrant environment or target machine is required to run this\ncommand. Or%!(VAGRANT_COMMA) not.`
+
+	errorExit, ok := ParseErrorExit(input)
+
+	assert.True(t, ok)
+	assert.Equal(t, ErrorExit{
+		Class:   "Vagrant::Errors::NoEnvironmentError",
+		Message: "A Vagrant environment or target machine is required to run this\ncommand. Or, not.",
+	}, errorExit)
+	assert.Equal(t, errorExit.Message, ParseVagrantError(input))
+
+	_, ok = ParseErrorExit("1,,ui,info,fine")
+	assert.False(t, ok)
+}
+
+func TestParseStatus(t *testing.T) {
+	input := `1688688549,node1,metadata,provider,docker
+	1688688549,node2,metadata,provider,docker
+	1688688549,node1,provider-name,docker
+	1688688549,node1,state,not_created
+	1688688549,node1,state-human-short,not created
+	1688688549,node1,state-human-long,The environment has not yet been created. Run %!(VAGRANT_COMMA)vagrant up%!(VAGRANT_COMMA).
+	1688688549,node2,state,running
+	1688688549,,ui,info,Current machine states:`
+
+	expected := []MachineStatus{
+		{
+			Name:            "node1",
+			Provider:        "docker",
+			State:           "not_created",
+			StateHumanShort: "not created",
+			StateHumanLong:  "The environment has not yet been created. Run ,vagrant up,.",
+		},
+		{
+			Name:     "node2",
+			Provider: "docker",
+			State:    "running",
+		},
+	}
+
+	assert.EqualValues(t, expected, ParseStatus(input))
+	assert.Empty(t, ParseStatus(""))
+}
+
+func TestParseGlobalStatus(t *testing.T) {
+	input := `1672263560,,metadata,machine-count,2
+	1672263560,,machine-id,12deee0
+	1672263560,,provider-name,libvirt
+	1672263560,,machine-home,/home/braheezy/vagrant-envs/violet-test/env1
+	1672263560,,state,running
+	1672263560,,machine-id,200d64a
+	1672263560,,provider-name,virtualbox
+	1672263560,,machine-home,/home/braheezy/vagrant-envs/violet-test/env2
+	1672263560,,state,poweroff
+	1672263560,,ui,info,id
+	1672263560,,ui,info,name
+	1672263560,,ui,info,provider
+	1672263560,,ui,info,state
+	1672263560,,ui,info,directory
+	1672263560,,ui,info,
+	1672263560,,ui,info,--------------------------------------------------------------------------------
+	1672263560,,ui,info,12deee0
+	1672263560,,ui,info,web
+	1672263560,,ui,info,libvirt
+	1672263560,,ui,info,running
+	1672263560,,ui,info,/home/braheezy/vagrant-envs/violet-test/env1
+	1672263560,,ui,info,
+	1672263560,,ui,info,200d64a
+	1672263560,,ui,info,db
+	1672263560,,ui,info,virtualbox
+	1672263560,,ui,info,poweroff
+	1672263560,,ui,info,/home/braheezy/vagrant-envs/violet-test/env2
+	1672263560,,ui,info,
+	1672263560,,ui,info, \nThe above shows information about all known Vagrant environments`
+
+	expected := []GlobalStatusEntry{
+		{
+			MachineID: "12deee0",
+			Name:      "web",
+			Provider:  "libvirt",
+			State:     "running",
+			Home:      "/home/braheezy/vagrant-envs/violet-test/env1",
+		},
+		{
+			MachineID: "200d64a",
+			Name:      "db",
+			Provider:  "virtualbox",
+			State:     "poweroff",
+			Home:      "/home/braheezy/vagrant-envs/violet-test/env2",
+		},
+	}
+
+	assert.EqualValues(t, expected, ParseGlobalStatus(input))
+	assert.Empty(t, ParseGlobalStatus("1,,metadata,machine-count,0"))
+}
+
+func TestParseBoxList(t *testing.T) {
+	input := `1695000000,,box-name,bento/ubuntu-22.04
+	1695000000,,box-provider,virtualbox
+	1695000000,,box-version,202309.08.0
+	1695000000,,box-architecture,amd64
+	1695000000,,box-name,generic/fedora38
+	1695000000,,box-provider,libvirt
+	1695000000,,box-version,4.3.4`
+
+	expected := []Box{
+		{Name: "bento/ubuntu-22.04", Provider: "virtualbox", Version: "202309.08.0", Architecture: "amd64"},
+		{Name: "generic/fedora38", Provider: "libvirt", Version: "4.3.4"},
+	}
+
+	assert.EqualValues(t, expected, ParseBoxList(input))
+}
+
+func TestParseSSHConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []SSHConfig
+	}{
+		{
+			name: "Verify plain output",
+			input: `Host web
+  HostName 192.168.121.10
+  User vagrant
+  Port 22
+  UserKnownHostsFile /dev/null
+  IdentityFile /home/braheezy/env/.vagrant/machines/web/libvirt/private_key
+  IdentityFile /home/braheezy/.vagrant.d/insecure_private_key
+
+Host db
+  HostName 127.0.0.1
+  User vagrant
+  Port 2222
+  IdentityFile "/home/braheezy/my env/.vagrant/machines/db/virtualbox/private_key"
+`,
+			expected: []SSHConfig{
+				{
+					Host:         "web",
+					HostName:     "192.168.121.10",
+					User:         "vagrant",
+					Port:         "22",
+					IdentityFile: "/home/braheezy/env/.vagrant/machines/web/libvirt/private_key",
+					Options: []SSHOption{
+						{Keyword: "HostName", Argument: "192.168.121.10"},
+						{Keyword: "User", Argument: "vagrant"},
+						{Keyword: "Port", Argument: "22"},
+						{Keyword: "UserKnownHostsFile", Argument: "/dev/null"},
+						{Keyword: "IdentityFile", Argument: "/home/braheezy/env/.vagrant/machines/web/libvirt/private_key"},
+						{Keyword: "IdentityFile", Argument: "/home/braheezy/.vagrant.d/insecure_private_key"},
+					},
+				},
+				{
+					Host:         "db",
+					HostName:     "127.0.0.1",
+					User:         "vagrant",
+					Port:         "2222",
+					IdentityFile: "/home/braheezy/my env/.vagrant/machines/db/virtualbox/private_key",
+					Options: []SSHOption{
+						{Keyword: "HostName", Argument: "127.0.0.1"},
+						{Keyword: "User", Argument: "vagrant"},
+						{Keyword: "Port", Argument: "2222"},
+						{Keyword: "IdentityFile", Argument: "/home/braheezy/my env/.vagrant/machines/db/virtualbox/private_key"},
+					},
+				},
+			},
+		},
+		{
+			name:  "Verify machine-readable output",
+			input: `1695000000,default,ui,info,Host default\n  HostName 127.0.0.1\n  User vagrant\n  Port 2200`,
+			expected: []SSHConfig{
+				{
+					Host:     "default",
+					HostName: "127.0.0.1",
+					User:     "vagrant",
+					Port:     "2200",
+					Options: []SSHOption{
+						{Keyword: "HostName", Argument: "127.0.0.1"},
+						{Keyword: "User", Argument: "vagrant"},
+						{Keyword: "Port", Argument: "2200"},
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		assert.EqualValues(t, test.expected, ParseSSHConfig(test.input), test.name)
+	}
+}
@@ -0,0 +1,184 @@
+package vagrant
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The only version of the machine index format that's understood.
+const machineIndexVersion = 1
+
+// Vagrant shows the first few characters of a machine's UUID as its ID, and
+// accepts them anywhere the full UUID is.
+const shortIDLength = 7
+
+// MachineIndexEntry is a machine as recorded in Vagrant's machine index.
+type MachineIndexEntry struct {
+	// ID is the full UUID of the machine.
+	ID       string
+	Name     string
+	Provider string
+	// State as of the last time Vagrant looked, just like `global-status`.
+	State string
+	// VagrantfilePath is the directory holding the machine's Vagrantfile.
+	VagrantfilePath string
+	// LocalDataPath is the .vagrant directory of the environment.
+	LocalDataPath string
+}
+
+// The on-disk format of the machine index.
+type machineIndexFile struct {
+	Version  int `json:"version"`
+	Machines map[string]struct {
+		Name            string `json:"name"`
+		Provider        string `json:"provider"`
+		State           string `json:"state"`
+		VagrantfilePath string `json:"vagrantfile_path"`
+		LocalDataPath   string `json:"local_data_path"`
+	} `json:"machines"`
+}
+
+// ParseMachineIndex turns the contents of a machine index file into entries,
+// ordered by directory and then name.
+func ParseMachineIndex(data []byte) ([]MachineIndexEntry, error) {
+	var index machineIndexFile
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("Error parsing the machine index: %w", err)
+	}
+	if index.Version != machineIndexVersion {
+		return nil, fmt.Errorf("unsupported machine index version %v", index.Version)
+	}
+
+	var entries []MachineIndexEntry
+	for id, machine := range index.Machines {
+		entries = append(entries, MachineIndexEntry{
+			ID:              id,
+			Name:            machine.Name,
+			Provider:        machine.Provider,
+			State:           machine.State,
+			VagrantfilePath: machine.VagrantfilePath,
+			LocalDataPath:   machine.LocalDataPath,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].VagrantfilePath != entries[j].VagrantfilePath {
+			return entries[i].VagrantfilePath < entries[j].VagrantfilePath
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	return entries, nil
+}
+
+// VagrantHome is where Vagrant keeps its global state, VAGRANT_HOME or ~/.vagrant.d.
+func (c *VagrantClient) VagrantHome() (string, error) {
+	// Like exec, the last one set wins
+	for _, variable := range slices.Backward(c.Env) {
+		if home, ok := strings.CutPrefix(variable, "VAGRANT_HOME="); ok {
+			if home == "" {
+				break
+			}
+			return home, nil
+		}
+	}
+	userHome, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(userHome, ".vagrant.d"), nil
+}
+
+// MachineIndexPath is the location of Vagrant's machine index file.
+func (c *VagrantClient) MachineIndexPath() (string, error) {
+	home, err := c.VagrantHome()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, "data", "machine-index", "index"), nil
+}
+
+// ReadMachineIndex reads every machine from Vagrant's machine index, without running Vagrant.
+func (c *VagrantClient) ReadMachineIndex() ([]MachineIndexEntry, error) {
+	path, err := c.MachineIndexPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseMachineIndex(data)
+}
+
+// GlobalStatus returns every machine Vagrant knows about. The machine index is
+// read directly when possible because starting Vagrant takes seconds, otherwise
+// `vagrant global-status` is run.
+//
+// Like `global-status --prune`, machines whose directory is gone are left out.
+func (c *VagrantClient) GlobalStatus(ctx context.Context) ([]GlobalStatusEntry, error) {
+	index, err := c.ReadMachineIndex()
+	if err != nil {
+		output, err := c.GetGlobalStatusContext(ctx)
+		if err != nil {
+			return nil, explainError(output, err)
+		}
+		return ParseGlobalStatus(output), nil
+	}
+
+	var entries []GlobalStatusEntry
+	for _, machine := range index {
+		if _, err := os.Stat(machine.VagrantfilePath); err != nil {
+			continue
+		}
+		entries = append(entries, GlobalStatusEntry{
+			MachineID: machine.ID[:min(len(machine.ID), shortIDLength)],
+			Name:      machine.Name,
+			Provider:  machine.Provider,
+			State:     machine.State,
+			Home:      machine.VagrantfilePath,
+		})
+	}
+	return entries, nil
+}
+
+// WatchMachineIndex checks the machine index every interval and sends on the
+// returned channel when its contents change, until ctx is done. Changes that
+// happen before the last one is received are merged into it.
+func (c *VagrantClient) WatchMachineIndex(ctx context.Context, interval time.Duration) <-chan struct{} {
+	changes := make(chan struct{}, 1)
+	go func() {
+		defer close(changes)
+		path, err := c.MachineIndexPath()
+		if err != nil {
+			return
+		}
+		last, _ := os.ReadFile(path)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			current, err := os.ReadFile(path)
+			// Vagrant replaces the file as it writes it, so it may be briefly missing
+			if err != nil || bytes.Equal(current, last) {
+				continue
+			}
+			last = current
+			select {
+			case changes <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return changes
+}
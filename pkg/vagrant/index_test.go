@@ -0,0 +1,118 @@
+package vagrant
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMachineIndex = `{
+	"version": 1,
+	"machines": {
+		"200d64a5f3c84a4f9a8e0f2b1c3d4e5f": {
+			"local_data_path": "%[1]v/env2/.vagrant",
+			"name": "db",
+			"provider": "virtualbox",
+			"state": "poweroff",
+			"vagrantfile_name": null,
+			"vagrantfile_path": "%[1]v/env2",
+			"updated_at": null,
+			"extra_data": {"box": {"name": "generic/fedora38", "provider": "virtualbox", "version": "4.3.4"}}
+		},
+		"12deee0aa3b14c7d8e9f0a1b2c3d4e5f": {
+			"local_data_path": "%[1]v/env1/.vagrant",
+			"name": "web",
+			"provider": "libvirt",
+			"state": "running",
+			"vagrantfile_name": null,
+			"vagrantfile_path": "%[1]v/env1",
+			"updated_at": null
+		}
+	}
+}`
+
+// Write a machine index under a new VAGRANT_HOME and return a client that reads it.
+func newIndexClient(t *testing.T, index string) (*VagrantClient, string) {
+	dir := t.TempDir()
+	home := filepath.Join(dir, "vagrant.d")
+	require.NoError(t, os.MkdirAll(filepath.Join(home, "data", "machine-index"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(home, "data", "machine-index", "index"), []byte(index), 0o644))
+	// Fail loudly if the CLI is used
+	return &VagrantClient{ExecPath: filepath.Join(dir, "no-vagrant"), Env: []string{"VAGRANT_HOME=" + home}}, dir
+}
+
+func TestParseMachineIndex(t *testing.T) {
+	entries, err := ParseMachineIndex([]byte(`{"version": 1, "machines": {
+		"b": {"name": "db", "provider": "virtualbox", "state": "poweroff", "vagrantfile_path": "/envs/a", "local_data_path": "/envs/a/.vagrant"},
+		"a": {"name": "web", "provider": "libvirt", "state": "running", "vagrantfile_path": "/envs/a", "local_data_path": "/envs/a/.vagrant"}
+	}}`))
+	require.NoError(t, err)
+	assert.EqualValues(t, []MachineIndexEntry{
+		{ID: "b", Name: "db", Provider: "virtualbox", State: "poweroff", VagrantfilePath: "/envs/a", LocalDataPath: "/envs/a/.vagrant"},
+		{ID: "a", Name: "web", Provider: "libvirt", State: "running", VagrantfilePath: "/envs/a", LocalDataPath: "/envs/a/.vagrant"},
+	}, entries)
+
+	_, err = ParseMachineIndex([]byte(`{"version": 2, "machines": {}}`))
+	assert.Error(t, err, "Verify unknown versions are rejected")
+	_, err = ParseMachineIndex([]byte(`not json`))
+	assert.Error(t, err)
+}
+
+func TestGlobalStatusFromIndex(t *testing.T) {
+	dir := t.TempDir()
+	client, _ := newIndexClient(t, fmt.Sprintf(testMachineIndex, dir))
+	// env2 was deleted, so like --prune it's left out
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "env1"), 0o755))
+
+	entries, err := client.GlobalStatus(context.Background())
+	require.NoError(t, err)
+	assert.EqualValues(t, []GlobalStatusEntry{
+		{MachineID: "12deee0", Name: "web", Provider: "libvirt", State: "running", Home: filepath.Join(dir, "env1")},
+	}, entries)
+}
+
+func TestVagrantHome(t *testing.T) {
+	client := &VagrantClient{Env: []string{"PATH=/bin", "VAGRANT_HOME=/opt/vagrant.d"}}
+	path, err := client.MachineIndexPath()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("/opt/vagrant.d", "data", "machine-index", "index"), path)
+
+	userHome, err := os.UserHomeDir()
+	require.NoError(t, err)
+	home, err := (&VagrantClient{}).VagrantHome()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(userHome, ".vagrant.d"), home)
+}
+
+func TestWatchMachineIndex(t *testing.T) {
+	client, dir := newIndexClient(t, fmt.Sprintf(testMachineIndex, "/envs"))
+	path := filepath.Join(dir, "vagrant.d", "data", "machine-index", "index")
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := client.WatchMachineIndex(ctx, 10*time.Millisecond)
+
+	// Writing the same contents isn't a change
+	require.NoError(t, os.WriteFile(path, []byte(fmt.Sprintf(testMachineIndex, "/envs")), 0o644))
+	select {
+	case <-changes:
+		t.Fatal("unchanged index was reported")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	require.NoError(t, os.WriteFile(path, []byte(fmt.Sprintf(testMachineIndex, "/elsewhere")), 0o644))
+	select {
+	case <-changes:
+	case <-time.After(time.Second):
+		t.Fatal("change to the index wasn't reported")
+	}
+
+	cancel()
+	for range changes {
+		// Drain until closed
+	}
+}
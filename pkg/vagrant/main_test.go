@@ -0,0 +1,20 @@
+package vagrant
+
+import (
+	"os"
+	"testing"
+
+	"github.com/braheezy/violet/pkg/vagrant/vagranttest"
+)
+
+func TestMain(m *testing.M) {
+	// The tests run Vagrant as a fake, which is this test binary
+	vagranttest.Main()
+	os.Exit(m.Run())
+}
+
+// A client that runs a fake Vagrant following script.
+func newFakeClient(t *testing.T, script vagranttest.Script) (*VagrantClient, *vagranttest.Fake) {
+	fake := vagranttest.New(t, script)
+	return &VagrantClient{ExecPath: fake.ExecPath, Env: fake.Env(), Timeout: DefaultTimeout, GracePeriod: DefaultGracePeriod}, fake
+}
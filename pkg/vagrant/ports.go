@@ -0,0 +1,40 @@
+package vagrant
+
+import (
+	"context"
+	"strconv"
+)
+
+// ForwardedPort is a port on the host that leads to a port on a machine, as listed by `vagrant port`.
+type ForwardedPort struct {
+	Guest int
+	Host  int
+}
+
+// ParsePorts turns the output of `vagrant port --machine-readable` into the machine's
+// forwarded ports, in the order Vagrant listed them.
+func ParsePorts(output string) []ForwardedPort {
+	var ports []ForwardedPort
+	for _, event := range ParseEvents(output) {
+		if event.Type != "forwarded_port" {
+			continue
+		}
+		guest, guestErr := strconv.Atoi(event.Value(0))
+		host, hostErr := strconv.Atoi(event.Value(1))
+		if guestErr != nil || hostErr != nil {
+			continue
+		}
+		ports = append(ports, ForwardedPort{Guest: guest, Host: host})
+	}
+	return ports
+}
+
+// GetPorts returns the forwarded ports of machine, a machine ID or a name in the working directory.
+// Vagrant fails for machines that haven't been created.
+func GetPorts(ctx context.Context, r Runner, machine string) ([]ForwardedPort, error) {
+	output, err := r.Run(ctx, "", "port", machine, "--machine-readable")
+	if err != nil {
+		return nil, err
+	}
+	return ParsePorts(output), nil
+}
@@ -0,0 +1,45 @@
+package vagrant
+
+import (
+	"context"
+	"testing"
+
+	"github.com/braheezy/violet/pkg/vagrant/vagranttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePorts(t *testing.T) {
+	output := vagranttest.Transcript(t, "testdata/port.txt")
+	assert.Equal(t, []ForwardedPort{{Guest: 22, Host: 2222}, {Guest: 80, Host: 8080}, {Guest: 443, Host: 8443}}, ParsePorts(output))
+
+	assert.Empty(t, ParsePorts("1695000000,web,forwarded_port,http,8080"), "Verify ports that aren't numbers are skipped")
+	assert.Empty(t, ParsePorts("1695000000,web,ui,info,The machine has no configured forwarded ports"))
+}
+
+func TestGetPorts(t *testing.T) {
+	sim := newTestSimulator()
+	ctx := context.Background()
+
+	ports, err := GetPorts(ctx, sim, "5d6e7f8")
+	require.NoError(t, err)
+	assert.Equal(t, []ForwardedPort{{Guest: 22, Host: 2223}}, ports, "Verify the SSH port matches ssh-config")
+
+	_, err = GetPorts(ctx, sim, "9a8b")
+	var commandErr *CommandError
+	require.ErrorAs(t, err, &commandErr, "Verify machines that don't exist yet have no ports")
+	assert.Equal(t, "Vagrant::Errors::VMNotCreatedError", commandErr.Class)
+}
+
+func TestVagrantClientGetPorts(t *testing.T) {
+	client, _ := newFakeClient(t, vagranttest.Script{
+		Commands: []vagranttest.Command{
+			{Args: []string{"port", "12deee0", "--machine-readable"}, Output: vagranttest.Transcript(t, "testdata/port.txt")},
+		},
+	})
+
+	ports, err := client.GetPorts("12deee0")
+	require.NoError(t, err)
+	assert.Len(t, ports, 3)
+	assert.Equal(t, ForwardedPort{Guest: 80, Host: 8080}, ports[1])
+}
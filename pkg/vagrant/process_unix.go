@@ -0,0 +1,22 @@
+//go:build !windows
+
+package vagrant
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// Signals sent, in order, to stop a cancelled command.
+var terminationSignals = []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGKILL}
+
+// Run the command in its own process group so it and everything it spawns
+// can be signalled together.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+func signalProcessGroup(cmd *exec.Cmd, sig os.Signal) error {
+	return syscall.Kill(-cmd.Process.Pid, sig.(syscall.Signal))
+}
@@ -0,0 +1,17 @@
+//go:build windows
+
+package vagrant
+
+import (
+	"os"
+	"os/exec"
+)
+
+// Windows has no process group signals, so the only option is to kill.
+var terminationSignals = []os.Signal{os.Kill}
+
+func setProcessGroup(cmd *exec.Cmd) {}
+
+func signalProcessGroup(cmd *exec.Cmd, sig os.Signal) error {
+	return cmd.Process.Kill()
+}
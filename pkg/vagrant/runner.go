@@ -0,0 +1,57 @@
+package vagrant
+
+import (
+	"context"
+	"os/exec"
+)
+
+// Runner runs Vagrant commands. VagrantClient runs the real Vagrant, Simulator pretends to.
+type Runner interface {
+	// GlobalStatus returns every machine Vagrant knows about.
+	GlobalStatus(ctx context.Context) ([]GlobalStatusEntry, error)
+	// Status returns the --machine-readable output of `vagrant status` for target,
+	// a machine ID or name, or for every machine in the environment in dir when
+	// target is empty.
+	Status(ctx context.Context, dir string, target string) (string, error)
+	// Run runs Vagrant with args in dir and returns its output once it's done.
+	// An empty dir is the working directory. When Vagrant explains why it
+	// failed, the error is a *CommandError.
+	Run(ctx context.Context, dir string, args ...string) (string, error)
+	// Stream starts Vagrant with args in dir and returns its output as it's printed.
+	Stream(ctx context.Context, dir string, args ...string) *Stream
+}
+
+var _ Runner = (*VagrantClient)(nil)
+
+// Run runs Vagrant with args in dir, see Runner.
+func (c *VagrantClient) Run(ctx context.Context, dir string, args ...string) (string, error) {
+	if dir == "" {
+		dir = c.workingDir
+	}
+	output, err := c.runArgs(ctx, dir, args...)
+	return output, explainError(output, err)
+}
+
+// Status runs `vagrant status` for target or the environment in dir, see Runner.
+func (c *VagrantClient) Status(ctx context.Context, dir string, target string) (string, error) {
+	return c.Run(ctx, dir, statusArgs(target)...)
+}
+
+func statusArgs(target string) []string {
+	if target == "" {
+		return []string{"status", "--machine-readable"}
+	}
+	return []string{"status", target, "--machine-readable"}
+}
+
+// Command returns the command to run Vagrant with args in dir, for when Vagrant
+// needs the terminal to itself e.g. `vagrant ssh`. An empty dir is the working directory.
+func (c *VagrantClient) Command(dir string, args ...string) *exec.Cmd {
+	if dir == "" {
+		dir = c.workingDir
+	}
+	cmd := exec.Command(c.ExecPath, args...)
+	cmd.Env = c.Env
+	cmd.Dir = dir
+	return cmd
+}
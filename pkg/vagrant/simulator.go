@@ -0,0 +1,405 @@
+package vagrant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SimulatedMachine is a machine that only exists in a Simulator.
+type SimulatedMachine struct {
+	// ID is the machine's unique ID. Like Vagrant, any prefix of it finds the machine.
+	ID       string
+	Name     string
+	Provider string
+	State    string
+	// Home is the directory of the machine's environment.
+	Home      string
+	Snapshots []string
+	// Ports are forwarded as well as the SSH port every machine gets.
+	Ports []ForwardedPort
+}
+
+// Simulator is a Runner that pretends to be Vagrant, with machines that only
+// exist in memory. It understands status, global-status, the commands that
+// change the state of machines, snapshots, ssh-config, port and listing boxes.
+// Anything else fails the way Vagrant does.
+//
+// It's safe to use from several goroutines at once.
+type Simulator struct {
+	// Delay is how long each line of output takes to be printed, like a slow Vagrant.
+	Delay time.Duration
+	// Boxes are what `box list` lists.
+	Boxes []Box
+
+	mu       sync.Mutex
+	machines []SimulatedMachine
+}
+
+var _ Runner = (*Simulator)(nil)
+
+// What each command says it's doing and the state machines end up in.
+// An empty state means the state doesn't change.
+var simulatedCommands = map[string]struct {
+	action string
+	state  string
+}{
+	"up":        {"Bringing machine 'up'...", "running"},
+	"halt":      {"Attempting graceful shutdown of VM...", "poweroff"},
+	"reload":    {"Reloading...", "running"},
+	"provision": {"Running provisioner: shell...", ""},
+	"suspend":   {"Saving VM state and suspending execution...", "saved"},
+	"resume":    {"Resuming suspended VM...", "running"},
+	"destroy":   {"Destroying VM and associated drives...", "not_created"},
+}
+
+// NewSimulator returns a Simulator with machines.
+func NewSimulator(machines ...SimulatedMachine) *Simulator {
+	return &Simulator{machines: slices.Clone(machines)}
+}
+
+// Machines returns the simulated machines as they are now.
+func (s *Simulator) Machines() []SimulatedMachine {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	machines := make([]SimulatedMachine, len(s.machines))
+	for i, machine := range s.machines {
+		machine.Snapshots = slices.Clone(machine.Snapshots)
+		machine.Ports = slices.Clone(machine.Ports)
+		machines[i] = machine
+	}
+	return machines
+}
+
+// GlobalStatus returns every simulated machine.
+func (s *Simulator) GlobalStatus(ctx context.Context) ([]GlobalStatusEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var entries []GlobalStatusEntry
+	for _, machine := range s.Machines() {
+		entries = append(entries, GlobalStatusEntry{
+			MachineID: machine.ID,
+			Name:      machine.Name,
+			Provider:  machine.Provider,
+			State:     machine.State,
+			Home:      machine.Home,
+		})
+	}
+	return entries, nil
+}
+
+// Status pretends to run `vagrant status`, see Runner.
+func (s *Simulator) Status(ctx context.Context, dir string, target string) (string, error) {
+	return s.Run(ctx, dir, statusArgs(target)...)
+}
+
+// Run pretends to run Vagrant with args in dir, see Runner.
+func (s *Simulator) Run(ctx context.Context, dir string, args ...string) (string, error) {
+	stream := s.Stream(ctx, dir, args...)
+	var output []string
+	for line := range stream.Lines {
+		output = append(output, line.Raw)
+	}
+	return strings.Join(output, "\n"), stream.Wait()
+}
+
+// Stream pretends to run Vagrant with args in dir, see Runner. Machines change
+// state once all the output has been printed.
+func (s *Simulator) Stream(ctx context.Context, dir string, args ...string) *Stream {
+	lines := make(chan OutputLine)
+	stream := &Stream{Lines: lines, done: make(chan struct{})}
+	output, apply, failure := s.simulate(dir, args)
+	if failure != nil {
+		output = append(output, simulatedLine("", "error-exit", failure.Class, failure.Message))
+	}
+
+	go func() {
+		defer close(stream.done)
+		defer close(lines)
+		for _, line := range output {
+			select {
+			case <-ctx.Done():
+				stream.err = fmt.Errorf("Error waiting for the command to complete: %w", ctx.Err())
+				return
+			case <-time.After(s.Delay):
+			}
+			select {
+			case lines <- parseOutputLine(line):
+			case <-ctx.Done():
+				stream.err = fmt.Errorf("Error waiting for the command to complete: %w", ctx.Err())
+				return
+			}
+		}
+		if failure != nil {
+			stream.err = &CommandError{ErrorExit: *failure, Err: errors.New("Error waiting for the command to complete: exit status 1")}
+			return
+		}
+		if apply != nil {
+			apply()
+		}
+	}()
+	return stream
+}
+
+// Work out what Vagrant would print for args in dir, and how machines change
+// once it's done. Returns why it failed, if it would.
+func (s *Simulator) simulate(dir string, args []string) (output []string, apply func(), failure *ErrorExit) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var command string
+	var positional []string
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		if command == "" {
+			command = arg
+		} else {
+			positional = append(positional, arg)
+		}
+	}
+	target := ""
+	if len(positional) > 0 {
+		target = positional[0]
+	}
+
+	switch command {
+	case "global-status":
+		for _, machine := range s.machines {
+			output = append(output,
+				simulatedLine("", "machine-id", machine.ID),
+				simulatedLine("", "provider-name", machine.Provider),
+				simulatedLine("", "machine-home", machine.Home),
+				simulatedLine("", "state", machine.State),
+			)
+		}
+		return output, nil, nil
+
+	case "status":
+		indexes, failure := s.find(dir, target)
+		if failure != nil {
+			return nil, nil, failure
+		}
+		for _, i := range indexes {
+			machine := s.machines[i]
+			output = append(output,
+				simulatedLine(machine.Name, "metadata", "provider", machine.Provider),
+				simulatedLine(machine.Name, "provider-name", machine.Provider),
+				simulatedLine(machine.Name, "state", machine.State),
+				simulatedLine(machine.Name, "state-human-short", strings.ReplaceAll(machine.State, "_", " ")),
+				simulatedLine(machine.Name, "state-human-long", ""),
+			)
+		}
+		return output, nil, nil
+
+	case "snapshot":
+		if len(positional) < 2 {
+			return nil, nil, &ErrorExit{Class: "Vagrant::Errors::CLIInvalidUsage", Message: "Usage: vagrant snapshot <subcommand> <machine> [<name>]"}
+		}
+		return s.simulateSnapshot(dir, positional[0], positional[1], positional[2:])
+
+	case "ssh-config":
+		indexes, failure := s.find(dir, target)
+		if failure != nil {
+			return nil, nil, failure
+		}
+		for _, i := range indexes {
+			machine := s.machines[i]
+			if machine.State != "running" {
+				return nil, nil, &ErrorExit{
+					Class:   "Vagrant::Errors::SSHNotReady",
+					Message: "The provider for this Vagrant-managed machine is reporting that it\nis not yet ready for SSH.",
+				}
+			}
+			// Each machine gets its own forwarded port, like Vagrant does
+			config := fmt.Sprintf("Host %v\n  HostName 127.0.0.1\n  User vagrant\n  Port %v\n  IdentityFile %v\n  IdentitiesOnly yes\n",
+				machine.Name, 2222+i, filepath.Join(machine.Home, ".vagrant", "machines", machine.Name, machine.Provider, "private_key"))
+			output = append(output, simulatedLine(machine.Name, "ui", "info", config))
+		}
+		return output, nil, nil
+
+	case "port":
+		indexes, failure := s.find(dir, target)
+		if failure != nil {
+			return nil, nil, failure
+		}
+		machine := s.machines[indexes[0]]
+		if machine.State == "not_created" {
+			return nil, nil, &ErrorExit{
+				Class:   "Vagrant::Errors::VMNotCreatedError",
+				Message: "The VM must be created before running this command. Run `vagrant up` first.",
+			}
+		}
+		// The same SSH port ssh-config gives
+		ports := append([]ForwardedPort{{Guest: 22, Host: 2222 + indexes[0]}}, machine.Ports...)
+		for _, port := range ports {
+			output = append(output, simulatedLine(machine.Name, "forwarded_port", strconv.Itoa(port.Guest), strconv.Itoa(port.Host)))
+		}
+		return output, nil, nil
+
+	case "box":
+		if target != "list" {
+			break
+		}
+		for _, box := range s.Boxes {
+			output = append(output,
+				simulatedLine("", "box-name", box.Name),
+				simulatedLine("", "box-provider", box.Provider),
+				simulatedLine("", "box-version", box.Version),
+			)
+			if box.Architecture != "" {
+				output = append(output, simulatedLine("", "box-architecture", box.Architecture))
+			}
+		}
+		return output, nil, nil
+	}
+
+	simulated, ok := simulatedCommands[command]
+	if !ok {
+		return nil, nil, &ErrorExit{
+			Class:   "Vagrant::Errors::CLIInvalidUsage",
+			Message: fmt.Sprintf("The simulator doesn't know how to run: vagrant %v", strings.Join(args, " ")),
+		}
+	}
+	indexes, failure := s.find(dir, target)
+	if failure != nil {
+		return nil, nil, failure
+	}
+	for _, i := range indexes {
+		output = append(output, simulatedLine(s.machines[i].Name, "ui", "info", fmt.Sprintf("==> %v: %v", s.machines[i].Name, simulated.action)))
+	}
+	apply = func() {
+		if simulated.state == "" {
+			return
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for _, i := range indexes {
+			s.machines[i].State = simulated.state
+		}
+	}
+	return output, apply, nil
+}
+
+func (s *Simulator) simulateSnapshot(dir string, subcommand string, target string, rest []string) (output []string, apply func(), failure *ErrorExit) {
+	indexes, failure := s.find(dir, target)
+	if failure != nil {
+		return nil, nil, failure
+	}
+	i := indexes[0]
+	machine := s.machines[i]
+	name := ""
+	if len(rest) > 0 {
+		name = rest[0]
+	}
+
+	switch subcommand {
+	case "list":
+		output = append(output, simulatedLine(machine.Name, "ui", "output", "==> "+machine.Name+":"))
+		if len(machine.Snapshots) == 0 {
+			return append(output, simulatedLine(machine.Name, "ui", "output", "No snapshots have been taken yet!")), nil, nil
+		}
+		for _, snapshot := range machine.Snapshots {
+			output = append(output, simulatedLine(machine.Name, "ui", "detail", snapshot))
+		}
+		return output, nil, nil
+	case "save":
+		if slices.Contains(machine.Snapshots, name) {
+			return nil, nil, &ErrorExit{Class: "VagrantPlugins::CommandSnapshot::Errors::SnapshotConflict", Message: "A snapshot with the name " + name + " already exists."}
+		}
+		output = append(output, simulatedLine(machine.Name, "ui", "info", "==> "+machine.Name+": Snapshotting the machine as '"+name+"'..."))
+		return output, func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.machines[i].Snapshots = append(s.machines[i].Snapshots, name)
+		}, nil
+	case "push":
+		name = fmt.Sprintf("push_%v_%v", time.Now().Unix(), len(machine.Snapshots))
+		output = append(output, simulatedLine(machine.Name, "ui", "info", "==> "+machine.Name+": Snapshotting the machine as '"+name+"'..."))
+		return output, func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.machines[i].Snapshots = append(s.machines[i].Snapshots, name)
+		}, nil
+	case "pop":
+		// The last one pushed
+		pushed := -1
+		for j, snapshot := range machine.Snapshots {
+			if strings.HasPrefix(snapshot, "push_") {
+				pushed = j
+			}
+		}
+		if pushed < 0 {
+			return nil, nil, &ErrorExit{Class: "VagrantPlugins::CommandSnapshot::Errors::NoPushedSnapshot", Message: "No pushed snapshot found!"}
+		}
+		name = machine.Snapshots[pushed]
+		output = append(output, simulatedLine(machine.Name, "ui", "info", "==> "+machine.Name+": Restoring the snapshot '"+name+"'..."))
+		return output, func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.machines[i].State = "running"
+			s.machines[i].Snapshots = slices.Delete(s.machines[i].Snapshots, pushed, pushed+1)
+		}, nil
+	case "restore", "delete":
+		if !slices.Contains(machine.Snapshots, name) {
+			return nil, nil, &ErrorExit{Class: "VagrantPlugins::CommandSnapshot::Errors::SnapshotNotFound", Message: "The snapshot name '" + name + "' was not found for the\nvirtual machine '" + machine.Name + "'."}
+		}
+		output = append(output, simulatedLine(machine.Name, "ui", "info", "==> "+machine.Name+": "+subcommand+" snapshot '"+name+"'..."))
+		return output, func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if subcommand == "delete" {
+				s.machines[i].Snapshots = slices.DeleteFunc(s.machines[i].Snapshots, func(snapshot string) bool { return snapshot == name })
+			} else {
+				s.machines[i].State = "running"
+			}
+		}, nil
+	}
+	return nil, nil, &ErrorExit{Class: "Vagrant::Errors::CLIInvalidUsage", Message: "The simulator doesn't know how to run: vagrant snapshot " + subcommand}
+}
+
+// The indexes of the machines target means, like Vagrant would find them from dir.
+func (s *Simulator) find(dir string, target string) ([]int, *ErrorExit) {
+	var indexes []int
+	for i, machine := range s.machines {
+		inDir := dir != "" && filepath.Clean(machine.Home) == filepath.Clean(dir)
+		switch {
+		case target == "" && inDir,
+			target != "" && strings.HasPrefix(machine.ID, target),
+			target != "" && machine.Name == target && (inDir || dir == ""):
+			indexes = append(indexes, i)
+		}
+	}
+	if len(indexes) > 0 {
+		return indexes, nil
+	}
+	if target == "" {
+		return nil, &ErrorExit{
+			Class:   "Vagrant::Errors::NoEnvironmentError",
+			Message: "A Vagrant environment or target machine is required to run this\ncommand.",
+		}
+	}
+	return nil, &ErrorExit{
+		Class:   "Vagrant::Errors::MachineNotFound",
+		Message: "The machine with the name '" + target + "' was not found configured for\nthis Vagrant environment.",
+	}
+}
+
+// A line of machine-readable output, escaped like Vagrant does.
+func simulatedLine(target string, kind string, data ...string) string {
+	escaped := make([]string, len(data))
+	for i, value := range data {
+		value = strings.ReplaceAll(value, ",", "%!(VAGRANT_COMMA)")
+		value = strings.ReplaceAll(value, "\n", `\n`)
+		escaped[i] = strings.ReplaceAll(value, "\r", `\r`)
+	}
+	return fmt.Sprintf("%v,%v,%v,%v", time.Now().Unix(), target, kind, strings.Join(escaped, ","))
+}
@@ -0,0 +1,109 @@
+package vagrant
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSimulator() *Simulator {
+	return NewSimulator(
+		SimulatedMachine{ID: "1a2b3c4", Name: "web", Provider: "virtualbox", State: "running", Home: "/envs/site"},
+		SimulatedMachine{ID: "5d6e7f8", Name: "db", Provider: "virtualbox", State: "poweroff", Home: "/envs/site"},
+		SimulatedMachine{ID: "9a8b7c6", Name: "default", Provider: "libvirt", State: "not_created", Home: "/envs/tool"},
+	)
+}
+
+func TestSimulatorStatus(t *testing.T) {
+	sim := newTestSimulator()
+	ctx := context.Background()
+
+	output, err := sim.Status(ctx, "/envs/site", "")
+	require.NoError(t, err)
+	assert.EqualValues(t, []MachineStatus{
+		{Name: "web", Provider: "virtualbox", State: "running", StateHumanShort: "running"},
+		{Name: "db", Provider: "virtualbox", State: "poweroff", StateHumanShort: "poweroff"},
+	}, ParseStatus(output))
+
+	output, err = sim.Status(ctx, "", "5d6")
+	require.NoError(t, err)
+	assert.EqualValues(t, []MachineStatus{{Name: "db", Provider: "virtualbox", State: "poweroff", StateHumanShort: "poweroff"}}, ParseStatus(output), "Verify IDs can be shortened")
+
+	_, err = sim.Status(ctx, "/elsewhere", "")
+	var commandErr *CommandError
+	require.ErrorAs(t, err, &commandErr)
+	assert.Equal(t, "Vagrant::Errors::NoEnvironmentError", commandErr.Class)
+
+	_, err = sim.Status(ctx, "/envs/site", "nope")
+	require.ErrorAs(t, err, &commandErr)
+	assert.Equal(t, "Vagrant::Errors::MachineNotFound", commandErr.Class)
+}
+
+func TestSimulatorChangesState(t *testing.T) {
+	sim := newTestSimulator()
+	ctx := context.Background()
+
+	stream := sim.Stream(ctx, "/envs/site", "halt", "--machine-readable")
+	var text []string
+	for line := range stream.Lines {
+		text = append(text, line.Text())
+	}
+	require.NoError(t, stream.Wait())
+	assert.Equal(t, []string{
+		"==> web: Attempting graceful shutdown of VM...",
+		"==> db: Attempting graceful shutdown of VM...",
+	}, text)
+
+	entries, err := sim.GlobalStatus(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "poweroff", entries[0].State)
+	assert.Equal(t, "poweroff", entries[1].State)
+	assert.Equal(t, "not_created", entries[2].State, "Verify other environments are left alone")
+
+	_, err = sim.Run(ctx, "", "up", "9a8b7c6", "--machine-readable")
+	require.NoError(t, err)
+	assert.Equal(t, "running", sim.Machines()[2].State)
+
+	_, err = sim.Run(ctx, "", "package", "--machine-readable")
+	assert.Error(t, err, "Verify unknown commands fail")
+}
+
+func TestSimulatorSnapshots(t *testing.T) {
+	sim := newTestSimulator()
+	ctx := context.Background()
+
+	snapshots, err := ListSnapshots(ctx, sim, "1a2b3c4")
+	require.NoError(t, err)
+	assert.Empty(t, snapshots)
+
+	require.NoError(t, SaveSnapshot(ctx, sim, "1a2b3c4", "before"))
+	require.NoError(t, PushSnapshot(ctx, sim, "1a2b3c4"))
+	snapshots, err = ListSnapshots(ctx, sim, "1a2b3c4")
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	assert.Equal(t, Snapshot{Machine: "web", Name: "before"}, snapshots[0])
+
+	require.NoError(t, PopSnapshot(ctx, sim, "1a2b3c4"))
+	assert.Error(t, SaveSnapshot(ctx, sim, "1a2b3c4", "before"), "Verify names can't be reused")
+	require.NoError(t, DeleteSnapshot(ctx, sim, "1a2b3c4", "before"))
+	assert.Error(t, RestoreSnapshot(ctx, sim, "1a2b3c4", "before"))
+	assert.Empty(t, sim.Machines()[0].Snapshots)
+}
+
+func TestSimulatorCancel(t *testing.T) {
+	sim := newTestSimulator()
+	sim.Delay = time.Hour
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := sim.Stream(ctx, "/envs/site", "up", "--machine-readable")
+	cancel()
+	for range stream.Lines {
+		// Drain until closed
+	}
+	assert.True(t, errors.Is(stream.Wait(), context.Canceled))
+	assert.Equal(t, "running", sim.Machines()[0].State)
+	assert.Equal(t, "poweroff", sim.Machines()[1].State, "Verify canceled commands change nothing")
+}
@@ -0,0 +1,110 @@
+package vagrant
+
+import (
+	"context"
+	"strings"
+)
+
+// Snapshot is a saved point in time of a machine, as listed by `vagrant snapshot list`.
+type Snapshot struct {
+	// Machine is the name of the machine the snapshot belongs to, if Vagrant said.
+	Machine string
+	Name    string
+}
+
+// ParseSnapshotList turns the output of `vagrant snapshot list --machine-readable` into Snapshots.
+func ParseSnapshotList(output string) []Snapshot {
+	var snapshots []Snapshot
+	for _, event := range ParseEvents(output) {
+		if event.Type != "ui" {
+			continue
+		}
+		switch event.Value(0) {
+		case "output":
+			// Machines without snapshots say so, followed by a detail line explaining snapshots.
+			if strings.HasPrefix(event.Value(1), "No snapshots") {
+				return nil
+			}
+		case "detail":
+			if name := strings.TrimSpace(event.Value(1)); name != "" {
+				snapshots = append(snapshots, Snapshot{Machine: event.Target, Name: name})
+			}
+		}
+	}
+	return snapshots
+}
+
+// ListSnapshots returns the snapshots of machine, a machine ID or a name in the working directory.
+func ListSnapshots(ctx context.Context, r Runner, machine string) ([]Snapshot, error) {
+	output, err := r.Run(ctx, "", "snapshot", "list", machine, "--machine-readable")
+	if err != nil {
+		return nil, err
+	}
+	return ParseSnapshotList(output), nil
+}
+
+// SaveSnapshot takes a snapshot of machine called name.
+func SaveSnapshot(ctx context.Context, r Runner, machine string, name string) error {
+	return runSnapshot(ctx, r, "save", machine, name)
+}
+
+// RestoreSnapshot puts machine back to the way it was when the snapshot called name was taken.
+func RestoreSnapshot(ctx context.Context, r Runner, machine string, name string) error {
+	return runSnapshot(ctx, r, "restore", machine, name)
+}
+
+// DeleteSnapshot deletes the snapshot of machine called name.
+func DeleteSnapshot(ctx context.Context, r Runner, machine string, name string) error {
+	return runSnapshot(ctx, r, "delete", machine, name)
+}
+
+// PushSnapshot takes an unnamed snapshot of machine, to be restored with PopSnapshot.
+func PushSnapshot(ctx context.Context, r Runner, machine string) error {
+	return runSnapshot(ctx, r, "push", machine)
+}
+
+// PopSnapshot restores the last snapshot taken with PushSnapshot and deletes it.
+func PopSnapshot(ctx context.Context, r Runner, machine string) error {
+	return runSnapshot(ctx, r, "pop", machine)
+}
+
+func runSnapshot(ctx context.Context, r Runner, subcommand string, args ...string) error {
+	_, err := r.Run(ctx, "", SnapshotArgs(subcommand, args...)...)
+	return err
+}
+
+// SnapshotArgs are the arguments to run `vagrant snapshot <subcommand>` with args.
+func SnapshotArgs(subcommand string, args ...string) []string {
+	args = append([]string{"snapshot", subcommand}, args...)
+	return append(args, "--machine-readable")
+}
+
+// ListSnapshots returns the snapshots of machine, a machine ID or a name in the working directory.
+func (c *VagrantClient) ListSnapshots(ctx context.Context, machine string) ([]Snapshot, error) {
+	return ListSnapshots(ctx, c, machine)
+}
+
+// SaveSnapshot takes a snapshot of machine called name.
+func (c *VagrantClient) SaveSnapshot(ctx context.Context, machine string, name string) error {
+	return SaveSnapshot(ctx, c, machine, name)
+}
+
+// RestoreSnapshot puts machine back to the way it was when the snapshot called name was taken.
+func (c *VagrantClient) RestoreSnapshot(ctx context.Context, machine string, name string) error {
+	return RestoreSnapshot(ctx, c, machine, name)
+}
+
+// DeleteSnapshot deletes the snapshot of machine called name.
+func (c *VagrantClient) DeleteSnapshot(ctx context.Context, machine string, name string) error {
+	return DeleteSnapshot(ctx, c, machine, name)
+}
+
+// PushSnapshot takes an unnamed snapshot of machine, to be restored with PopSnapshot.
+func (c *VagrantClient) PushSnapshot(ctx context.Context, machine string) error {
+	return PushSnapshot(ctx, c, machine)
+}
+
+// PopSnapshot restores the last snapshot taken with PushSnapshot and deletes it.
+func (c *VagrantClient) PopSnapshot(ctx context.Context, machine string) error {
+	return PopSnapshot(ctx, c, machine)
+}
@@ -0,0 +1,70 @@
+package vagrant
+
+import (
+	"context"
+	"testing"
+
+	"github.com/braheezy/violet/pkg/vagrant/vagranttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSnapshotList(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []Snapshot
+	}{
+		{
+			name: "Verify snapshots are listed",
+			input: `1695000000,web,ui,output,==> web:
+			1695000000,web,ui,detail,before-upgrade
+			1695000000,web,ui,detail,push_1695000000_1234`,
+			expected: []Snapshot{
+				{Machine: "web", Name: "before-upgrade"},
+				{Machine: "web", Name: "push_1695000000_1234"},
+			},
+		},
+		{
+			name: "Verify no snapshots",
+			input: `1695000000,web,ui,output,No snapshots have been taken yet!
+			1695000000,web,ui,detail,Snapshot is a way to save the current state of a machine...`,
+			expected: nil,
+		},
+		{
+			name:     "Verify empty output",
+			input:    ``,
+			expected: nil,
+		},
+	}
+
+	for _, test := range tests {
+		assert.EqualValues(t, test.expected, ParseSnapshotList(test.input), test.name)
+	}
+}
+
+func TestVagrantClientSnapshots(t *testing.T) {
+	client, fake := newFakeClient(t, vagranttest.Script{
+		Commands: []vagranttest.Command{
+			{Args: []string{"snapshot", "list", "12deee0", "--machine-readable"}, Output: "1695000000,web,ui,detail,before-upgrade"},
+			{Args: []string{"snapshot", "*", "12deee0", "*", "--machine-readable"}},
+			{Args: []string{"snapshot", "*", "12deee0", "--machine-readable"}},
+		},
+	})
+	ctx := context.Background()
+
+	snapshots, err := client.ListSnapshots(ctx, "12deee0")
+	require.NoError(t, err)
+	assert.Equal(t, []Snapshot{{Machine: "web", Name: "before-upgrade"}}, snapshots)
+	require.NoError(t, client.SaveSnapshot(ctx, "12deee0", "clean"))
+	require.NoError(t, client.RestoreSnapshot(ctx, "12deee0", "clean"))
+	require.NoError(t, client.DeleteSnapshot(ctx, "12deee0", "clean"))
+	require.NoError(t, client.PushSnapshot(ctx, "12deee0"))
+	require.NoError(t, client.PopSnapshot(ctx, "12deee0"))
+
+	var subcommands []string
+	for _, call := range fake.Calls(t) {
+		subcommands = append(subcommands, call[1])
+	}
+	assert.Equal(t, []string{"list", "save", "restore", "delete", "push", "pop"}, subcommands)
+}
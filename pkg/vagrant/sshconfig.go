@@ -0,0 +1,20 @@
+package vagrant
+
+import (
+	"context"
+	"errors"
+)
+
+// GetSSHConfig returns how to connect to machine, a machine ID or a name in the working directory.
+// Vagrant fails for machines that aren't running.
+func GetSSHConfig(ctx context.Context, r Runner, machine string) (SSHConfig, error) {
+	output, err := r.Run(ctx, "", "ssh-config", machine, "--machine-readable")
+	if err != nil {
+		return SSHConfig{}, err
+	}
+	configs := ParseSSHConfig(output)
+	if len(configs) == 0 {
+		return SSHConfig{}, errors.New("vagrant didn't say how to connect to " + machine)
+	}
+	return configs[0], nil
+}
@@ -0,0 +1,27 @@
+package vagrant
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSSHConfig(t *testing.T) {
+	sim := newTestSimulator()
+	ctx := context.Background()
+
+	config, err := GetSSHConfig(ctx, sim, "1a2b")
+	require.NoError(t, err)
+	assert.Equal(t, "web", config.Host)
+	assert.Equal(t, "127.0.0.1", config.HostName)
+	assert.Equal(t, "2222", config.Port)
+	assert.Equal(t, "vagrant", config.User)
+	assert.Equal(t, "/envs/site/.vagrant/machines/web/virtualbox/private_key", config.IdentityFile)
+
+	_, err = GetSSHConfig(ctx, sim, "5d6e7f8")
+	var commandErr *CommandError
+	require.ErrorAs(t, err, &commandErr, "Verify machines that aren't running can't be connected to")
+	assert.Equal(t, "Vagrant::Errors::SSHNotReady", commandErr.Class)
+}
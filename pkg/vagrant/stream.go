@@ -0,0 +1,114 @@
+package vagrant
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"strings"
+)
+
+// OutputLine is a single line of output from a streamed Vagrant command.
+// The Event is only filled in when the line is machine-readable.
+type OutputLine struct {
+	Event
+	// Raw is the line exactly as Vagrant printed it.
+	Raw string
+}
+
+// Split a line of Vagrant output into its machine-readable parts, if it has any.
+func parseOutputLine(raw string) OutputLine {
+	event, _ := ParseEvent(raw)
+	return OutputLine{Event: event, Raw: raw}
+}
+
+// Text returns the part of the line meant for humans. For `ui` lines that's
+// the message, for error-exit the error, for other machine-readable lines the data,
+// otherwise the raw line.
+func (l OutputLine) Text() string {
+	switch l.Type {
+	case "":
+		return l.Raw
+	case "ui", "error-exit":
+		return l.Value(1)
+	default:
+		return strings.Join(l.Data, ",")
+	}
+}
+
+// Stream is a running Vagrant command whose output is delivered line by line.
+type Stream struct {
+	// Lines receives each line of output and is closed once the command exits.
+	Lines <-chan OutputLine
+	done  chan struct{}
+	err   error
+}
+
+// Wait blocks until the command exits and returns the error it exited with.
+func (s *Stream) Wait() error {
+	<-s.done
+	return s.err
+}
+
+// StreamCommand starts a Vagrant command and returns its output as it's printed.
+// It runs for as long as it needs to, the client Timeout isn't applied.
+// Pass --machine-readable in command to have Target and Type filled in.
+func (c *VagrantClient) StreamCommand(ctx context.Context, command string) *Stream {
+	return c.StreamCommandInDirectory(ctx, command, c.workingDir)
+}
+
+// StreamCommandInDirectory is like StreamCommand but runs command in dir.
+func (c *VagrantClient) StreamCommandInDirectory(ctx context.Context, command string, dir string) *Stream {
+	return c.streamArgs(ctx, dir, strings.Split(command, " ")...)
+}
+
+// Stream starts Vagrant with args in dir and returns its output as it's printed.
+// An empty dir is the client's working directory.
+func (c *VagrantClient) Stream(ctx context.Context, dir string, args ...string) *Stream {
+	if dir == "" {
+		dir = c.workingDir
+	}
+	return c.streamArgs(ctx, dir, args...)
+}
+
+func (c *VagrantClient) streamArgs(ctx context.Context, dir string, args ...string) *Stream {
+	lines := make(chan OutputLine, 64)
+	stream := &Stream{Lines: lines, done: make(chan struct{})}
+	reader, writer := io.Pipe()
+
+	// Why Vagrant failed, if it said
+	var errorExit *ErrorExit
+	scanned := make(chan struct{})
+	go func() {
+		defer close(scanned)
+		defer close(lines)
+		scanner := bufio.NewScanner(reader)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := parseOutputLine(scanner.Text())
+			if line.Type == "error-exit" && errorExit == nil {
+				errorExit = &ErrorExit{Class: line.Value(0), Message: line.Value(1)}
+			}
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+				// Nobody may be listening anymore, keep draining so Vagrant isn't blocked.
+			}
+		}
+		// Unblock the writer if the scanner gave up early, e.g. on a huge line.
+		reader.CloseWithError(scanner.Err())
+	}()
+
+	go func() {
+		err := c.run(ctx, args, dir, writer)
+		writer.Close()
+		<-scanned
+		if err != nil && errorExit != nil {
+			// Like Run, so the reason isn't lost when the output is only shown to people
+			err = &CommandError{ErrorExit: *errorExit, Err: err}
+		}
+		stream.err = err
+		close(stream.done)
+	}()
+
+	return stream
+}
@@ -0,0 +1,76 @@
+package vagrant
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/braheezy/violet/pkg/vagrant/vagranttest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamCommand(t *testing.T) {
+	client, _ := newFakeClient(t, vagranttest.Script{
+		Commands: []vagranttest.Command{
+			{
+				Args: []string{"up", "--machine-readable"},
+				Output: `1,default,ui,info,Booting%!(VAGRANT_COMMA)VM...
+				2,default,state,running
+				plain`,
+				LineDelay: 10 * time.Millisecond,
+			},
+		},
+	})
+
+	stream := client.StreamCommand(context.Background(), "up --machine-readable")
+
+	var lines []OutputLine
+	for line := range stream.Lines {
+		lines = append(lines, line)
+	}
+	require.NoError(t, stream.Wait())
+	require.Equal(t, []OutputLine{
+		{
+			Raw:   "1,default,ui,info,Booting%!(VAGRANT_COMMA)VM...",
+			Event: Event{Timestamp: time.Unix(1, 0).UTC(), Target: "default", Type: "ui", Data: []string{"info", "Booting,VM..."}},
+		},
+		{
+			Raw:   "2,default,state,running",
+			Event: Event{Timestamp: time.Unix(2, 0).UTC(), Target: "default", Type: "state", Data: []string{"running"}},
+		},
+		{Raw: "plain"},
+	}, lines)
+	require.Equal(t, "Booting,VM...", lines[0].Text())
+	require.Equal(t, "running", lines[1].Text())
+	require.Equal(t, "plain", lines[2].Text())
+}
+
+func TestStreamCommand_Error(t *testing.T) {
+	client := &VagrantClient{ExecPath: "/fake/path/to/vagrant"}
+
+	stream := client.StreamCommand(context.Background(), "up")
+
+	for range stream.Lines {
+	}
+	require.ErrorContains(t, stream.Wait(), "Error executing")
+}
+
+func TestStreamCommand_ErrorExit(t *testing.T) {
+	client, _ := newFakeClient(t, vagranttest.Script{
+		Commands: []vagranttest.Command{
+			{
+				Args:     []string{"up", "--machine-readable"},
+				Output:   "1695000000,,error-exit,Vagrant::Errors::NoEnvironmentError,A Vagrant environment or target machine is required",
+				ExitCode: 1,
+			},
+		},
+	})
+
+	stream := client.StreamCommand(context.Background(), "up --machine-readable")
+	for range stream.Lines {
+	}
+	var commandErr *CommandError
+	require.ErrorAs(t, stream.Wait(), &commandErr, "Verify streams explain errors like Run does")
+	require.Equal(t, "Vagrant::Errors::NoEnvironmentError", commandErr.Class)
+	require.Equal(t, "A Vagrant environment or target machine is required", commandErr.Error())
+}
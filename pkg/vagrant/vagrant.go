@@ -2,13 +2,25 @@
 package vagrant
 
 import (
-	"bufio"
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"regexp"
+	"slices"
 	"strings"
+	"time"
+)
+
+const (
+	// DefaultTimeout is the time limit applied to commands whose output is returned all at once
+	// and whose context has no deadline. Streamed commands, like up, never get one.
+	DefaultTimeout = 30 * time.Minute
+	// DefaultGracePeriod is how long Vagrant gets to exit after each termination signal.
+	DefaultGracePeriod = 10 * time.Second
 )
 
 // VagrantClient know how to runs Vagrant commands
@@ -17,6 +29,11 @@ type VagrantClient struct {
 	ExecPath string
 	// Environment variables used when running Vagrant commands
 	Env []string
+	// Timeout is applied to commands whose context has no deadline, except streamed ones
+	// which can take as long as they need. Zero means no limit.
+	Timeout time.Duration
+	// GracePeriod is how long to wait between termination signals when a command is cancelled.
+	GracePeriod time.Duration
 	// The working directory for Vagrant commands
 	workingDir string
 }
@@ -30,21 +47,27 @@ func NewVagrantClient() (*VagrantClient, error) {
 	}
 
 	return &VagrantClient{
-		ExecPath: execPath,
-		Env:      os.Environ(),
+		ExecPath:    execPath,
+		Env:         os.Environ(),
+		Timeout:     DefaultTimeout,
+		GracePeriod: DefaultGracePeriod,
 	}, nil
 }
 
 // Return the version of Vagrant.
 // NB: Good way to check VagrantClient is working
 func (c *VagrantClient) GetVersion() (string, error) {
-	cmd := exec.Command(c.ExecPath, "--version")
-	result, err := cmd.CombinedOutput()
+	return c.GetVersionContext(context.Background())
+}
+
+// GetVersionContext is like GetVersion but stops Vagrant when ctx is done.
+func (c *VagrantClient) GetVersionContext(ctx context.Context) (string, error) {
+	result, err := c.runArgs(ctx, c.workingDir, "--version")
 	if err != nil {
-		return "", errors.New("unable to run vagrant binary")
+		return "", fmt.Errorf("unable to run vagrant binary: %w", err)
 	}
 	// Parse out version string
-	version := string(result)
+	version := result
 	r := regexp.MustCompile(`Vagrant (\d+.\d+.\d+)`)
 	matches := r.FindStringSubmatch(version)
 
@@ -58,54 +81,166 @@ func (c *VagrantClient) GetVersion() (string, error) {
 }
 
 func (c *VagrantClient) GetGlobalStatus() (result string, err error) {
-	return c.RunCommand("global-status --prune --machine-readable")
+	return c.GetGlobalStatusContext(context.Background())
+}
+
+// GetGlobalStatusContext is like GetGlobalStatus but stops Vagrant when ctx is done.
+func (c *VagrantClient) GetGlobalStatusContext(ctx context.Context) (result string, err error) {
+	return c.RunCommandContext(ctx, "global-status --prune --machine-readable")
 }
 
 func (c *VagrantClient) GetStatusForID(machineID string) (result string, err error) {
-	return c.RunCommand(fmt.Sprintf("status %v --machine-readable", machineID))
+	return c.GetStatusForIDContext(context.Background(), machineID)
+}
+
+// GetStatusForIDContext is like GetStatusForID but stops Vagrant when ctx is done.
+func (c *VagrantClient) GetStatusForIDContext(ctx context.Context, machineID string) (result string, err error) {
+	return c.RunCommandContext(ctx, fmt.Sprintf("status %v --machine-readable", machineID))
+}
+
+// GetSSHConfig returns how to connect to the machine with machineID, see ParseSSHConfig.
+func (c *VagrantClient) GetSSHConfig(machineID string) (SSHConfig, error) {
+	return c.GetSSHConfigContext(context.Background(), machineID)
+}
+
+// GetSSHConfigContext is like GetSSHConfig but stops Vagrant when ctx is done.
+func (c *VagrantClient) GetSSHConfigContext(ctx context.Context, machineID string) (SSHConfig, error) {
+	return GetSSHConfig(ctx, c, machineID)
+}
+
+// GetPorts returns the forwarded ports of the machine with machineID, see ParsePorts.
+func (c *VagrantClient) GetPorts(machineID string) ([]ForwardedPort, error) {
+	return c.GetPortsContext(context.Background(), machineID)
+}
+
+// GetPortsContext is like GetPorts but stops Vagrant when ctx is done.
+func (c *VagrantClient) GetPortsContext(ctx context.Context, machineID string) ([]ForwardedPort, error) {
+	return GetPorts(ctx, c, machineID)
 }
 
 // Run a Vagrant command and return the result as a string with newlines.
 func (c *VagrantClient) RunCommand(command string) (output string, err error) {
-	cmd := exec.Command(c.ExecPath, strings.Split(command, " ")...)
-	cmd.Env = c.Env
-	cmd.Dir = c.workingDir
+	return c.RunCommandContext(context.Background(), command)
+}
 
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return "", errors.New("Error creating stdout pipe: " + err.Error())
+// RunCommandContext is like RunCommand but stops Vagrant when ctx is done.
+// If ctx has no deadline, the client Timeout is applied.
+func (c *VagrantClient) RunCommandContext(ctx context.Context, command string) (output string, err error) {
+	return c.runInDirectory(ctx, command, c.workingDir)
+}
+
+func (c *VagrantClient) RunCommandInDirectory(command string, dir string) (output string, err error) {
+	return c.RunCommandInDirectoryContext(context.Background(), command, dir)
+}
+
+// RunCommandInDirectoryContext is like RunCommandInDirectory but stops Vagrant when ctx is done.
+func (c *VagrantClient) RunCommandInDirectoryContext(ctx context.Context, command string, dir string) (output string, err error) {
+	return c.runInDirectory(ctx, command, dir)
+}
+
+func (c *VagrantClient) runInDirectory(ctx context.Context, command string, dir string) (output string, err error) {
+	return c.runArgs(ctx, dir, strings.Split(command, " ")...)
+}
+
+// Like runInDirectory, for arguments that may contain spaces. The client Timeout is
+// applied if ctx has no deadline.
+func (c *VagrantClient) runArgs(ctx context.Context, dir string, args ...string) (output string, err error) {
+	_, hasDeadline := ctx.Deadline()
+	if !hasDeadline && c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	var buf bytes.Buffer
+	err = c.run(ctx, args, dir, &buf)
+	// Only ours can be exceeded, there was no deadline before
+	if !hasDeadline && errors.Is(err, context.DeadlineExceeded) {
+		err = fmt.Errorf("vagrant %v took longer than the %v timeout: %w", strings.Join(args, " "), c.Timeout, err)
 	}
-	cmd.Stderr = cmd.Stdout
+	return buf.String(), err
+}
+
+// CommandError is returned when Vagrant fails and explains why.
+type CommandError struct {
+	ErrorExit
+	// The error from running the command
+	Err error
+}
 
-	scanner := bufio.NewScanner(stdout)
+func (e *CommandError) Error() string { return e.Message }
 
-	err = cmd.Start()
-	if err != nil {
-		return "", errors.New("Error executing: " + err.Error())
+func (e *CommandError) Unwrap() error { return e.Err }
+
+// Turn err into a CommandError if output holds the reason Vagrant failed.
+func explainError(output string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if errorExit, ok := ParseErrorExit(output); ok {
+		return &CommandError{ErrorExit: errorExit, Err: err}
 	}
+	return err
+}
 
+// Run Vagrant with args in dir, sending stdout and stderr to out.
+//
+// When ctx is done the whole process group is asked to stop, escalating through
+// the terminationSignals with GracePeriod in between so Vagrant has a chance
+// to clean up after itself.
+func (c *VagrantClient) run(ctx context.Context, args []string, dir string, out io.Writer) error {
+	cmd := exec.Command(c.ExecPath, args...)
+	cmd.Env = c.Env
+	cmd.Dir = dir
+	cmd.Stdout = out
+	cmd.Stderr = out
+	// Children of Vagrant may keep the output open after it exits.
+	cmd.WaitDelay = c.gracePeriod()
+	setProcessGroup(cmd)
+
+	if err := cmd.Start(); err != nil {
+		return errors.New("Error executing: " + err.Error())
+	}
+
+	exited := make(chan struct{})
 	go func() {
-		for scanner.Scan() {
-			output += scanner.Text() + "\n"
+		select {
+		case <-ctx.Done():
+			c.terminate(cmd, exited)
+		case <-exited:
 		}
 	}()
 
-	err = cmd.Wait()
+	err := cmd.Wait()
+	close(exited)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return fmt.Errorf("Error waiting for the command to complete: %w", ctxErr)
+	}
 	if err != nil {
-		return "", errors.New("Error waiting for the command to complete: " + err.Error())
+		return errors.New("Error waiting for the command to complete: " + err.Error())
 	}
-	return output, nil
+	return nil
 }
 
-func (c *VagrantClient) RunCommandInDirectory(command string, dir string) (output string, err error) {
-	c.workingDir = dir
-	result, err := c.RunCommand(command)
-	c.workingDir = ""
+// Signal the process group of cmd until it exits or we run out of signals.
+func (c *VagrantClient) terminate(cmd *exec.Cmd, exited <-chan struct{}) {
+	for _, sig := range terminationSignals {
+		if err := signalProcessGroup(cmd, sig); err != nil {
+			return
+		}
+		select {
+		case <-exited:
+			return
+		case <-time.After(c.gracePeriod()):
+		}
+	}
+}
 
-	if err != nil {
-		return "", err
+func (c *VagrantClient) gracePeriod() time.Duration {
+	if c.GracePeriod > 0 {
+		return c.GracePeriod
 	}
-	return result, nil
+	return DefaultGracePeriod
 }
 
 // Represents the result of a Vagrant command under the context of a single machine.
@@ -131,12 +266,10 @@ func Contains(slice []MachineInfo, item MachineInfo) bool {
 	return false
 }
 
+// Returns the message of the error Vagrant exited with, if there is one.
 func ParseVagrantError(output string) string {
-	errRegex := regexp.MustCompile(`^\s*\d+,(.*),error-exit,(.+)$`)
-	for _, line := range strings.Split(output, "\n") {
-		if m := errRegex.FindStringSubmatch(line); m != nil {
-			return m[2]
-		}
+	if errorExit, ok := ParseErrorExit(output); ok {
+		return errorExit.Message
 	}
 	return ""
 }
@@ -147,78 +280,57 @@ func ParseVagrantOutput(output string) []MachineInfo {
 	/*
 		This function operators on the output of --machine-readable Vagrant commands: https://developer.hashicorp.com/vagrant/docs/cli/machine-readable.
 
-		The format is:
-			timestamp,target,type,data...
-
-			timestamp is a Unix timestamp in UTC of when the message was printed.
-
-			target is the target of the following output. This is empty if the message is related to Vagrant globally. Otherwise, this is generally a machine name so you can relate output to a specific machine when multi-machine is in use.
-
-			type is the type of machine-readable message being outputted. There are a set of standard types which are covered later.
-
-			data is zero or more comma-separated values associated with the prior type. The exact amount and meaning of this data is type-dependent, so you must read the documentation associated with the type to understand fully.
+		Lines are parsed into Events first, see ParseEvents. Only the types in supportedFields are kept,
+		ParseStatus and ParseGlobalStatus give typed results for every field.
 	*/
-	// Compile regular expressions for each field.
-	fields := make(map[string]*regexp.Regexp)
 	// DEV: Add more fields here as needed.
 	supportedFields := []string{"metadata", "machine-id", "provider-name", "state", "state-human-long", "machine-home"}
-	for _, field := range supportedFields {
-		fields[field] = regexp.MustCompile(`^\s*\d+,(.*),` + field + `,(.+)$`)
-	}
 	var results []MachineInfo
 	var result MachineInfo
 	result.Fields = make(map[string]string)
-	for _, line := range strings.Split(output, "\n") {
-		// Check if the line matches any of the fields.
-		matched := false
-		for field, re := range fields {
-			if m := re.FindStringSubmatch(line); m != nil {
-				target := m[1]
-				// This loops manages findings for machines we might have already seen.
-				for _, r := range results {
-					// When the name matches (and isn't empty), it's a machine seen before.
-					if r.Name == target && r.Name != "" {
-						// We're about to switch the result to a different machine so make sure
-						// it's in the results list before we "drop" it.
-						if !Contains(results, result) {
-							results = append(results, result)
-						}
-						// Update this machine instead of whatever we we're tracking previously.
-						result = r
-					}
-				}
-				// Now, fill in machine data, keeping an eye out for new Names or MachineIDs, the clear indication a new machine has been found.
-				// metadata lines de-lineate machines and are a good place to grab the name.
-				if field == "metadata" {
-					// Save name if it's the first we've seen
-					if result.Name == "" {
-						// NB: target might be "" too, that's okay.
-						result.Name = target
-					} else if result.Name != target {
-						// New machine found. Create new Result
-						results = append(results, result)
-						result = MachineInfo{Name: target, Fields: make(map[string]string)}
-					}
-				} else if field == "machine-id" {
-					if result.MachineID != "" {
-						// New machine found. Create new Result
-						results = append(results, result)
-						result = MachineInfo{Name: target, MachineID: m[2], Fields: make(map[string]string)}
-					} else {
-						result.MachineID = m[2]
-					}
-				} else {
-					// Update the result with the field value.
-					result.Fields[field] = m[2]
+	for _, event := range ParseEvents(output) {
+		field := event.Type
+		value := strings.Join(event.Data, ",")
+		if !slices.Contains(supportedFields, field) || value == "" {
+			continue
+		}
+		target := event.Target
+		// This loops manages findings for machines we might have already seen.
+		for _, r := range results {
+			// When the name matches (and isn't empty), it's a machine seen before.
+			if r.Name == target && r.Name != "" {
+				// We're about to switch the result to a different machine so make sure
+				// it's in the results list before we "drop" it.
+				if !Contains(results, result) {
+					results = append(results, result)
 				}
-				// Found a field for this line, move on to next line
-				matched = true
-				break
+				// Update this machine instead of whatever we we're tracking previously.
+				result = r
 			}
 		}
-		if !matched {
-			// No field was matched, move on to the next line.
-			continue
+		// Now, fill in machine data, keeping an eye out for new Names or MachineIDs, the clear indication a new machine has been found.
+		// metadata lines de-lineate machines and are a good place to grab the name.
+		if field == "metadata" {
+			// Save name if it's the first we've seen
+			if result.Name == "" {
+				// NB: target might be "" too, that's okay.
+				result.Name = target
+			} else if result.Name != target {
+				// New machine found. Create new Result
+				results = append(results, result)
+				result = MachineInfo{Name: target, Fields: make(map[string]string)}
+			}
+		} else if field == "machine-id" {
+			if result.MachineID != "" {
+				// New machine found. Create new Result
+				results = append(results, result)
+				result = MachineInfo{Name: target, MachineID: value, Fields: make(map[string]string)}
+			} else {
+				result.MachineID = value
+			}
+		} else {
+			// Update the result with the field value.
+			result.Fields[field] = value
 		}
 	}
 	// Add the last result
@@ -1,9 +1,12 @@
 package vagrant
 
 import (
+	"context"
 	"os"
 	"testing"
+	"time"
 
+	"github.com/braheezy/violet/pkg/vagrant/vagranttest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -11,41 +14,50 @@ import (
 // Confirm the Vagrant Client can be successfully created
 func TestNewVagrantClient(t *testing.T) {
 	t.Run("Verify client when binary is available and accessible", func(t *testing.T) {
+		vagranttest.New(t, vagranttest.Script{}).Install(t)
 		client, err := NewVagrantClient()
 		require.NoError(t, err)
 		require.NotNil(t, client)
 		// Confirm the client can run
 		version, err := client.GetVersion()
 		require.NoError(t, err)
-		require.NotNil(t, version)
+		require.Equal(t, vagranttest.Version, version)
 	})
 	t.Run("Verify client when binary is not installed", func(t *testing.T) {
-		// Save the current PATH so we can restore it after
-		originalPathEnv := os.Getenv("PATH")
-		// Destroy PATH for next call
-		modifiedPathEnv := "/nothing"
-		os.Setenv("PATH", modifiedPathEnv)
+		t.Setenv("PATH", "/nothing")
 		client, err := NewVagrantClient()
 
 		assert.Nil(t, client)
 		require.ErrorContains(t, err, "vagrant binary not found")
-
-		// Restore PATH
-		os.Setenv("PATH", originalPathEnv)
 	})
 	t.Run("Verify client when binary is not available", func(t *testing.T) {
-		client, _ := NewVagrantClient()
-		client.ExecPath = "/fake/path/to/vagrant"
+		client := &VagrantClient{ExecPath: "/fake/path/to/vagrant"}
 
 		_, err := client.GetVersion()
 
 		require.ErrorContains(t, err, "unable to run vagrant binary")
 	})
+	t.Run("Verify asking for the version can be cancelled", func(t *testing.T) {
+		client, _ := newFakeClient(t, vagranttest.Script{
+			Commands: []vagranttest.Command{{Args: []string{"--version"}, Delay: 30 * time.Second}},
+		})
+		client.GracePeriod = 100 * time.Millisecond
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err := client.GetVersionContext(ctx)
+
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
 
 }
 
 func TestRunCommand(t *testing.T) {
-	client, _ := NewVagrantClient()
+	client, _ := newFakeClient(t, vagranttest.Script{
+		Commands: []vagranttest.Command{
+			{Args: []string{"global-status"}, Output: vagranttest.Transcript(t, "testdata/global-status.txt")},
+		},
+	})
 
 	t.Run("Verify a valid Vagrant command", func(t *testing.T) {
 		result, err := client.RunCommand("global-status")
@@ -53,19 +65,88 @@ func TestRunCommand(t *testing.T) {
 		require.Nil(t, err)
 		require.Greater(t, len(result), 40)
 	})
+	t.Run("Verify an unknown Vagrant command", func(t *testing.T) {
+		result, err := client.RunCommand("nonsense")
+
+		require.Error(t, err)
+		require.Contains(t, ParseVagrantError(result), "vagrant nonsense")
+	})
+}
+
+func TestRunCommandContext(t *testing.T) {
+	client, _ := newFakeClient(t, vagranttest.Script{
+		Commands: []vagranttest.Command{
+			{Args: []string{"up"}, Delay: 30 * time.Second},
+			{Args: []string{"halt"}, Delay: 100 * time.Millisecond},
+		},
+	})
+	client.GracePeriod = 100 * time.Millisecond
+	client.Timeout = 0
+
+	t.Run("Verify cancelled command is stopped", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+
+		start := time.Now()
+		_, err := client.RunCommandContext(ctx, "up")
+
+		require.ErrorIs(t, err, context.Canceled)
+		require.Less(t, time.Since(start), 5*time.Second)
+	})
+	t.Run("Verify default timeout is applied", func(t *testing.T) {
+		client.Timeout = 50 * time.Millisecond
+		defer func() { client.Timeout = 0 }()
+
+		_, err := client.RunCommandContext(context.Background(), "up")
+
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.ErrorContains(t, err, "vagrant up took longer than the 50ms timeout")
+	})
+	t.Run("Verify streamed commands have no timeout", func(t *testing.T) {
+		client.Timeout = time.Millisecond
+		defer func() { client.Timeout = 0 }()
+
+		stream := client.Stream(context.Background(), "", "halt")
+		for range stream.Lines {
+		}
+
+		require.NoError(t, stream.Wait())
+	})
+	t.Run("Verify per-call deadline wins over default timeout", func(t *testing.T) {
+		client.Timeout = time.Millisecond
+		defer func() { client.Timeout = 0 }()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		_, err := client.RunCommandContext(ctx, "halt")
+
+		require.NoError(t, err)
+	})
 }
 
 func TestGetGlobalStatus(t *testing.T) {
-	client, _ := NewVagrantClient()
+	client, _ := newFakeClient(t, vagranttest.Script{
+		Commands: []vagranttest.Command{
+			{Args: []string{"global-status", "--prune", "--machine-readable"}, Output: vagranttest.Transcript(t, "testdata/global-status.txt")},
+		},
+	})
 
 	result, err := client.GetGlobalStatus()
 
 	require.Nil(t, err)
 	require.NotEmpty(t, result)
+	require.Len(t, ParseGlobalStatus(result), 2)
 }
 
 func TestGetStatusForID(t *testing.T) {
-	client, _ := NewVagrantClient()
+	client, fake := newFakeClient(t, vagranttest.Script{
+		Commands: []vagranttest.Command{
+			{Args: []string{"status", "fake", "--machine-readable"}, Output: vagranttest.Transcript(t, "testdata/status-machine-not-found.txt"), ExitCode: 1},
+			{Args: []string{"status", "*", "--machine-readable"}, Output: vagranttest.Transcript(t, "testdata/status.txt")},
+			{Args: []string{"up", "*", "--machine-readable"}, Then: map[string]string{"web": "running"}},
+		},
+		States: map[string]string{"web": "shutoff"},
+	})
 
 	tests := []struct {
 		name      string
@@ -79,6 +160,11 @@ func TestGetStatusForID(t *testing.T) {
 			expected:  "",
 			wantError: true,
 		},
+		{
+			name:     "Test good ID",
+			input:    "12deee0",
+			expected: "1695000000,web,state,shutoff",
+		},
 	}
 	for _, test := range tests {
 		result, err := client.GetStatusForID(test.input)
@@ -87,10 +173,53 @@ func TestGetStatusForID(t *testing.T) {
 			require.Error(t, err)
 		}
 	}
+
+	t.Run("Verify status follows state changes", func(t *testing.T) {
+		_, err := client.RunCommand("up 12deee0 --machine-readable")
+		require.NoError(t, err)
+		require.Equal(t, "running", fake.State(t, "web"))
+
+		result, err := client.GetStatusForID("12deee0")
+		require.NoError(t, err)
+		require.Equal(t, "running", ParseStatus(result)[0].State)
+	})
+}
+
+func TestVagrantClientGetSSHConfig(t *testing.T) {
+	client, _ := newFakeClient(t, vagranttest.Script{
+		Commands: []vagranttest.Command{
+			{Args: []string{"ssh-config", "12deee0", "--machine-readable"}, Output: vagranttest.Transcript(t, "testdata/ssh-config.txt")},
+			{
+				Args:     []string{"ssh-config", "*", "--machine-readable"},
+				Output:   "1695000000,,error-exit,Vagrant::Errors::SSHNotReady,The provider for this Vagrant-managed machine is reporting that it\\nis not yet ready for SSH.",
+				ExitCode: 1,
+			},
+		},
+	})
+
+	config, err := client.GetSSHConfig("12deee0")
+	require.NoError(t, err)
+	require.Equal(t, "web", config.Host)
+	require.Equal(t, "192.168.121.10", config.HostName)
+	require.Equal(t, "22", config.Port)
+	require.Equal(t, "/home/braheezy/env/.vagrant/machines/web/libvirt/private_key", config.IdentityFile)
+	require.Len(t, config.Options, 11)
+
+	_, err = client.GetSSHConfig("5d6e7f8")
+	var commandErr *CommandError
+	require.ErrorAs(t, err, &commandErr)
+	require.Equal(t, "Vagrant::Errors::SSHNotReady", commandErr.Class)
 }
 
 func TestRunCommandInDirectory(t *testing.T) {
-	client, _ := NewVagrantClient()
+	project := t.TempDir()
+	client, fake := newFakeClient(t, vagranttest.Script{
+		Commands: []vagranttest.Command{
+			{Args: []string{"status", "--machine-readable"}, Dir: project, Output: vagranttest.Transcript(t, "testdata/status.txt")},
+			{Args: []string{"status", "--machine-readable"}, Output: vagranttest.Transcript(t, "testdata/status-no-environment.txt"), ExitCode: 1},
+		},
+		States: map[string]string{"web": "running"},
+	})
 	tests := []struct {
 		name      string
 		input     map[string]string
@@ -99,10 +228,15 @@ func TestRunCommandInDirectory(t *testing.T) {
 	}{
 		{
 			name:      "Non-existent vagrant project",
-			input:     map[string]string{"command": "status", "directory": "/tmp"},
+			input:     map[string]string{"command": "status --machine-readable", "directory": os.TempDir()},
 			expected:  "",
 			wantError: true,
 		},
+		{
+			name:     "Vagrant project",
+			input:    map[string]string{"command": "status --machine-readable", "directory": project},
+			expected: "1695000000,web,state,running",
+		},
 	}
 	for _, test := range tests {
 		result, err := client.RunCommandInDirectory(test.input["command"], test.input["directory"])
@@ -110,9 +244,11 @@ func TestRunCommandInDirectory(t *testing.T) {
 		require.Empty(t, client.workingDir)
 		if test.wantError {
 			require.Error(t, err)
+		} else {
+			require.NoError(t, err)
 		}
 	}
-
+	require.Len(t, fake.Calls(t), len(tests))
 }
 func TestParseVagrantOutput_StatusSingleEnv(t *testing.T) {
 	tests := []struct {
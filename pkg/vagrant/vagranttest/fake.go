@@ -0,0 +1,180 @@
+// Package vagranttest provides a fake Vagrant for testing code that runs Vagrant,
+// without needing Vagrant or a hypervisor installed.
+//
+// The fake is the test binary itself. Call Main from TestMain so that when the
+// test binary is started by a Fake, it acts like Vagrant instead of running tests:
+//
+//	func TestMain(m *testing.M) {
+//		vagranttest.Main()
+//		os.Exit(m.Run())
+//	}
+//
+// Each Fake follows a Script: recorded --machine-readable transcripts to replay
+// for the commands it's run with, the machine states they depend on and change,
+// and exit codes and delays to simulate.
+package vagranttest
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Tells the test binary to act as Vagrant, and where its Script is.
+const dirEnv = "VAGRANTTEST_DIR"
+
+// Version is what the fake says it is when the Script doesn't say otherwise.
+const Version = "2.4.1"
+
+// Script is everything a Fake does.
+type Script struct {
+	// Commands the fake answers. The first one that matches how it's run is used.
+	Commands []Command
+	// States holds the starting state of each machine, by name.
+	States map[string]string
+	// MachineIndex, if set, is written to the machine index under the fake's VAGRANT_HOME.
+	MachineIndex string
+}
+
+// Command is a scripted answer to running Vagrant with some arguments.
+type Command struct {
+	// Args must equal the arguments the fake is run with. A "*" matches any single argument.
+	Args []string
+	// Dir, if set, must equal the working directory.
+	Dir string
+	// When holds machine states that must all be current for this command to match.
+	When map[string]string
+
+	// Output is printed line by line to stdout. It's a text/template, where
+	// {{state "web"}} is the current state of the machine called web.
+	Output string
+	// ExitCode is what the fake exits with.
+	ExitCode int
+	// Delay is how long to wait before printing anything.
+	Delay time.Duration
+	// LineDelay is how long to wait before each line after the first.
+	LineDelay time.Duration
+
+	// Then holds the states machines are in once the command is done.
+	Then map[string]string
+}
+
+// Fake is a Vagrant executable following a Script.
+type Fake struct {
+	// ExecPath runs the fake. Use it as the VagrantClient ExecPath, along with Env.
+	ExecPath string
+	// Where the script, machine states and call log are kept
+	dir string
+}
+
+// New creates a Fake following script. It's cleaned up when the test ends.
+func New(t testing.TB, script Script) *Fake {
+	t.Helper()
+	execPath, err := os.Executable()
+	if err != nil {
+		t.Fatalf("vagranttest: finding the test binary: %v", err)
+	}
+	fake := &Fake{ExecPath: execPath, dir: t.TempDir()}
+
+	if script.States == nil {
+		script.States = map[string]string{}
+	}
+	if err := writeJSON(filepath.Join(fake.dir, scriptFile), script); err != nil {
+		t.Fatalf("vagranttest: %v", err)
+	}
+	if err := writeJSON(filepath.Join(fake.dir, statesFile), script.States); err != nil {
+		t.Fatalf("vagranttest: %v", err)
+	}
+	if script.MachineIndex != "" {
+		index := filepath.Join(fake.vagrantHome(), "data", "machine-index", "index")
+		if err := os.MkdirAll(filepath.Dir(index), 0o755); err != nil {
+			t.Fatalf("vagranttest: %v", err)
+		}
+		if err := os.WriteFile(index, []byte(script.MachineIndex), 0o644); err != nil {
+			t.Fatalf("vagranttest: %v", err)
+		}
+	}
+	return fake
+}
+
+// Env is the environment to run the fake with. It keeps the fake away from the
+// real VAGRANT_HOME.
+func (f *Fake) Env() []string {
+	return append(os.Environ(), dirEnv+"="+f.dir, "VAGRANT_HOME="+f.vagrantHome())
+}
+
+// Install puts the fake on PATH as vagrant, for code that looks Vagrant up
+// itself, until the test ends.
+func (f *Fake) Install(t testing.TB) {
+	t.Helper()
+	bin := filepath.Join(f.dir, "bin")
+	if err := os.MkdirAll(bin, 0o755); err != nil {
+		t.Fatalf("vagranttest: %v", err)
+	}
+	name := "vagrant"
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	link := filepath.Join(bin, name)
+	if err := os.Symlink(f.ExecPath, link); err != nil {
+		// Symlinks need extra privileges on Windows
+		data, err := os.ReadFile(f.ExecPath)
+		if err == nil {
+			err = os.WriteFile(link, data, 0o755)
+		}
+		if err != nil {
+			t.Fatalf("vagranttest: installing the fake: %v", err)
+		}
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv(dirEnv, f.dir)
+	t.Setenv("VAGRANT_HOME", f.vagrantHome())
+}
+
+// Calls returns the arguments of every time the fake was run, in order.
+func (f *Fake) Calls(t testing.TB) [][]string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(f.dir, callsFile))
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		t.Fatalf("vagranttest: %v", err)
+	}
+	var calls [][]string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var args []string
+		if err := json.Unmarshal([]byte(line), &args); err != nil {
+			t.Fatalf("vagranttest: reading calls: %v", err)
+		}
+		calls = append(calls, args)
+	}
+	return calls
+}
+
+// State returns the current state of machine.
+func (f *Fake) State(t testing.TB, machine string) string {
+	t.Helper()
+	states, err := readStates(f.dir)
+	if err != nil {
+		t.Fatalf("vagranttest: %v", err)
+	}
+	return states[machine]
+}
+
+// Transcript returns the contents of a recorded transcript, usually from testdata.
+func Transcript(t testing.TB, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("vagranttest: %v", err)
+	}
+	return string(data)
+}
+
+func (f *Fake) vagrantHome() string {
+	return filepath.Join(f.dir, "vagrant.d")
+}
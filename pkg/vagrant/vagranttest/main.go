@@ -0,0 +1,169 @@
+package vagranttest
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	scriptFile = "script.json"
+	statesFile = "states.json"
+	callsFile  = "calls.log"
+)
+
+// Main acts as Vagrant and exits, if the test binary was started by a Fake.
+// Otherwise it returns right away.
+func Main() {
+	dir := os.Getenv(dirEnv)
+	if dir == "" {
+		return
+	}
+	os.Exit(run(dir, os.Args[1:]))
+}
+
+// Act as Vagrant run with args, returning the exit code.
+func run(dir string, args []string) int {
+	var script Script
+	if err := readJSON(filepath.Join(dir, scriptFile), &script); err != nil {
+		return fail("Fake::Errors::BadScript", err.Error())
+	}
+	states, err := readStates(dir)
+	if err != nil {
+		return fail("Fake::Errors::BadScript", err.Error())
+	}
+	if err := recordCall(dir, args); err != nil {
+		return fail("Fake::Errors::BadScript", err.Error())
+	}
+	workingDir, _ := os.Getwd()
+
+	command, ok := match(script.Commands, args, workingDir, states)
+	if !ok {
+		if len(args) == 1 && args[0] == "--version" {
+			fmt.Println("Vagrant " + Version)
+			return 0
+		}
+		return fail("Fake::Errors::UnexpectedCommand", "no scripted command for: vagrant "+strings.Join(args, " "))
+	}
+
+	tmpl, err := template.New("output").Funcs(template.FuncMap{
+		"state": func(machine string) string { return states[machine] },
+	}).Parse(command.Output)
+	if err != nil {
+		return fail("Fake::Errors::BadScript", err.Error())
+	}
+	var output strings.Builder
+	if err := tmpl.Execute(&output, nil); err != nil {
+		return fail("Fake::Errors::BadScript", err.Error())
+	}
+
+	time.Sleep(command.Delay)
+	if output.Len() > 0 {
+		out := bufio.NewWriter(os.Stdout)
+		for i, line := range strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n") {
+			if i > 0 {
+				time.Sleep(command.LineDelay)
+			}
+			// Transcripts in Go source are often indented
+			fmt.Fprintln(out, strings.TrimLeft(line, " \t"))
+			// Flush each line so readers see output as it happens
+			out.Flush()
+		}
+	}
+
+	if len(command.Then) > 0 {
+		for machine, state := range command.Then {
+			states[machine] = state
+		}
+		if err := writeJSON(filepath.Join(dir, statesFile), states); err != nil {
+			return fail("Fake::Errors::BadScript", err.Error())
+		}
+	}
+	return command.ExitCode
+}
+
+// The first command that matches how the fake was run
+func match(commands []Command, args []string, workingDir string, states map[string]string) (Command, bool) {
+	for _, command := range commands {
+		if len(command.Args) != len(args) {
+			continue
+		}
+		matches := true
+		for i, arg := range command.Args {
+			if arg != "*" && arg != args[i] {
+				matches = false
+			}
+		}
+		if command.Dir != "" && !sameDir(command.Dir, workingDir) {
+			matches = false
+		}
+		for machine, state := range command.When {
+			if states[machine] != state {
+				matches = false
+			}
+		}
+		if matches {
+			return command, true
+		}
+	}
+	return Command{}, false
+}
+
+// Temporary directories are behind symlinks on some systems, e.g. /var on macOS.
+func sameDir(a string, b string) bool {
+	if resolved, err := filepath.EvalSymlinks(a); err == nil {
+		a = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(b); err == nil {
+		b = resolved
+	}
+	return filepath.Clean(a) == filepath.Clean(b)
+}
+
+// Exit the way Vagrant does when it fails with --machine-readable.
+func fail(class string, message string) int {
+	message = strings.ReplaceAll(message, ",", "%!(VAGRANT_COMMA)")
+	fmt.Printf("%v,,error-exit,%v,%v\n", time.Now().Unix(), class, message)
+	return 1
+}
+
+func recordCall(dir string, args []string) error {
+	data, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, callsFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+func readStates(dir string) (map[string]string, error) {
+	states := map[string]string{}
+	err := readJSON(filepath.Join(dir, statesFile), &states)
+	return states, err
+}
+
+func readJSON(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func writeJSON(path string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}